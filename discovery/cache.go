@@ -0,0 +1,87 @@
+package discovery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a cached API directory listing stays fresh.
+const DefaultCacheTTL = 24 * time.Hour
+
+// cache is the on-disk cache for the API directory. It is disabled (dir == "")
+// until SetCache is called.
+var cache struct {
+	mu  sync.Mutex
+	dir string
+	ttl time.Duration
+}
+
+// SetCache makes ListAPIs keep the API directory listing in dir and reuse it for
+// ttl. An empty dir disables caching, which is the default.
+func SetCache(dir string, ttl time.Duration) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.dir = dir
+	cache.ttl = ttl
+}
+
+// DefaultCacheDir returns the per-user cache directory for this tool, e.g.
+// ~/.cache/google-discovery-mcp on Linux.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "google-discovery-mcp"), nil
+}
+
+// cachePath returns the file caching the response for url, or "" when caching is
+// disabled. Entries are keyed by URL so different discovery roots don't mix.
+func cachePath(url string) (string, time.Duration) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.dir == "" {
+		return "", 0
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(cache.dir, "directory-"+hex.EncodeToString(sum[:8])+".json"), cache.ttl
+}
+
+// readCache returns the cached response for url if it is younger than the TTL.
+func readCache(url string) ([]byte, bool) {
+	path, ttl := cachePath(url)
+	if path == "" {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) >= ttl {
+		return nil, false
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // Path is derived from the cache directory
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// writeCache stores the response for url. Failing to cache is not fatal to the
+// caller, but the error is returned for callers that want to report it.
+func writeCache(url string, data []byte) error {
+	path, _ := cachePath(url)
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil { //nolint:gosec // Cached public API metadata
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
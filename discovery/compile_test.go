@@ -0,0 +1,77 @@
+package discovery
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeMCPPackage is a minimal stand-in for the MCP types package used by
+// generated handlers, so generated code can be compiled without network access.
+const fakeMCPPackage = `package mcp
+
+type CallToolRequest struct {
+	Params struct {
+		Name      string
+		Arguments any
+	}
+}
+
+type CallToolResult struct {
+	Text    string
+	IsError bool
+}
+
+func NewToolResultError(text string) *CallToolResult {
+	return &CallToolResult{Text: text, IsError: true}
+}
+`
+
+// fakeMCPImportPath is the import path of fakeMCPPackage inside runGenerated modules.
+const fakeMCPImportPath = "gentest/mcp"
+
+// runGenerated writes files into a throwaway module named "gentest" and runs
+// "go run ." in it, returning combined output. Paths are relative to the module
+// root; the fake MCP package is available at fakeMCPImportPath.
+func runGenerated(t *testing.T, files map[string]string) string {
+	t.Helper()
+	return runGo(t, files, "run", ".")
+}
+
+// runGo is like runGenerated but runs the go command with the given arguments.
+func runGo(t *testing.T, files map[string]string, args ...string) string {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	dir := t.TempDir()
+	all := map[string]string{
+		"go.mod":     "module gentest\n\ngo 1.24\n",
+		"mcp/mcp.go": fakeMCPPackage,
+	}
+	for name, content := range files {
+		all[name] = content
+	}
+	for name, content := range all {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goBin, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOPROXY=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go %s failed: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
@@ -0,0 +1,184 @@
+package discovery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DocumentDiff summarizes the differences between two versions of a Discovery Document.
+type DocumentDiff struct {
+	AddedMethods      []string       // Flattened method names, e.g. "videos.rate"
+	RemovedMethods    []string       // Flattened method names
+	ChangedMethods    []MethodChange // Methods present in both with a different signature
+	AddedSchemas      []string       // Schema names
+	RemovedSchemas    []string       // Schema names
+	AddedProperties   []string       // "Schema.property" for schemas present in both
+	RemovedProperties []string       // "Schema.property" for schemas present in both
+}
+
+// MethodChange describes how a method's signature changed.
+type MethodChange struct {
+	Name    string
+	Changes []string // Human-readable changes, e.g. "parameter removed: onBehalfOf"
+}
+
+// DiffDocuments compares two Discovery Documents. Methods are compared by signature:
+// HTTP method, path, parameters (type, repeated, required) and request/response schemas.
+func DiffDocuments(oldDoc, newDoc *Document) DocumentDiff {
+	var d DocumentDiff
+
+	oldMethods, newMethods := oldDoc.AllMethods(), newDoc.AllMethods()
+	for _, name := range sortedKeys(newMethods) {
+		if _, ok := oldMethods[name]; !ok {
+			d.AddedMethods = append(d.AddedMethods, name)
+		}
+	}
+	for _, name := range sortedKeys(oldMethods) {
+		newMethod, ok := newMethods[name]
+		if !ok {
+			d.RemovedMethods = append(d.RemovedMethods, name)
+			continue
+		}
+		if changes := diffMethod(oldMethods[name], newMethod); len(changes) > 0 {
+			d.ChangedMethods = append(d.ChangedMethods, MethodChange{Name: name, Changes: changes})
+		}
+	}
+
+	for _, name := range sortedKeys(newDoc.Schemas) {
+		if _, ok := oldDoc.Schemas[name]; !ok {
+			d.AddedSchemas = append(d.AddedSchemas, name)
+		}
+	}
+	for _, name := range sortedKeys(oldDoc.Schemas) {
+		newSchema, ok := newDoc.Schemas[name]
+		if !ok {
+			d.RemovedSchemas = append(d.RemovedSchemas, name)
+			continue
+		}
+		oldProps := oldDoc.Schemas[name].Properties
+		for _, prop := range sortedKeys(newSchema.Properties) {
+			if _, ok := oldProps[prop]; !ok {
+				d.AddedProperties = append(d.AddedProperties, name+"."+prop)
+			}
+		}
+		for _, prop := range sortedKeys(oldProps) {
+			if _, ok := newSchema.Properties[prop]; !ok {
+				d.RemovedProperties = append(d.RemovedProperties, name+"."+prop)
+			}
+		}
+	}
+
+	return d
+}
+
+// Empty reports whether the documents are equivalent as far as the diff is concerned.
+func (d DocumentDiff) Empty() bool {
+	return len(d.AddedMethods) == 0 && len(d.RemovedMethods) == 0 && len(d.ChangedMethods) == 0 &&
+		len(d.AddedSchemas) == 0 && len(d.RemovedSchemas) == 0 &&
+		len(d.AddedProperties) == 0 && len(d.RemovedProperties) == 0
+}
+
+// String returns a human-readable summary of the diff.
+func (d DocumentDiff) String() string {
+	if d.Empty() {
+		return "No changes.\n"
+	}
+	var b strings.Builder
+	section := func(title, marker string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "%s:\n", title)
+		for _, item := range items {
+			fmt.Fprintf(&b, "  %s %s\n", marker, item)
+		}
+	}
+	section("Added methods", "+", d.AddedMethods)
+	section("Removed methods", "-", d.RemovedMethods)
+	if len(d.ChangedMethods) > 0 {
+		b.WriteString("Changed methods:\n")
+		for _, c := range d.ChangedMethods {
+			fmt.Fprintf(&b, "  ~ %s\n", c.Name)
+			for _, change := range c.Changes {
+				fmt.Fprintf(&b, "      %s\n", change)
+			}
+		}
+	}
+	section("Added schemas", "+", d.AddedSchemas)
+	section("Removed schemas", "-", d.RemovedSchemas)
+	section("Added properties", "+", d.AddedProperties)
+	section("Removed properties", "-", d.RemovedProperties)
+	return b.String()
+}
+
+func diffMethod(oldMethod, newMethod *Method) []string {
+	var changes []string
+	if oldMethod.HTTPMethod != newMethod.HTTPMethod {
+		changes = append(changes, fmt.Sprintf("http method changed: %s -> %s", oldMethod.HTTPMethod, newMethod.HTTPMethod))
+	}
+	if oldMethod.Path != newMethod.Path {
+		changes = append(changes, fmt.Sprintf("path changed: %s -> %s", oldMethod.Path, newMethod.Path))
+	}
+	if a, b := schemaRefName(oldMethod.Request), schemaRefName(newMethod.Request); a != b {
+		changes = append(changes, fmt.Sprintf("request changed: %s -> %s", orNone(a), orNone(b)))
+	}
+	if a, b := schemaRefName(oldMethod.Response), schemaRefName(newMethod.Response); a != b {
+		changes = append(changes, fmt.Sprintf("response changed: %s -> %s", orNone(a), orNone(b)))
+	}
+
+	for _, name := range sortedKeys(newMethod.Parameters) {
+		if _, ok := oldMethod.Parameters[name]; !ok {
+			changes = append(changes, "parameter added: "+name)
+		}
+	}
+	for _, name := range sortedKeys(oldMethod.Parameters) {
+		newParam, ok := newMethod.Parameters[name]
+		if !ok {
+			changes = append(changes, "parameter removed: "+name)
+			continue
+		}
+		if a, b := paramSignature(oldMethod.Parameters[name]), paramSignature(newParam); a != b {
+			changes = append(changes, fmt.Sprintf("parameter changed: %s %s -> %s", name, a, b))
+		}
+	}
+	return changes
+}
+
+// paramSignature renders the type-relevant parts of a parameter, e.g. "[]string (required)".
+func paramSignature(p *Parameter) string {
+	sig := p.Type
+	if p.Format != "" {
+		sig += "/" + p.Format
+	}
+	if p.Repeated {
+		sig = "[]" + sig
+	}
+	if p.Required {
+		sig += " (required)"
+	}
+	return sig
+}
+
+func schemaRefName(ref *SchemaRef) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.Ref
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
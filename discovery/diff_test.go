@@ -0,0 +1,88 @@
+package discovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffDocuments(t *testing.T) {
+	oldDoc := &Document{
+		Schemas: map[string]*Schema{
+			"Video": {Properties: map[string]*Schema{"id": {Type: "string"}, "etag": {Type: "string"}}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						HTTPMethod: "GET",
+						Path:       "videos",
+						Parameters: map[string]*Parameter{
+							"part":       {Type: "string", Required: true},
+							"maxResults": {Type: "integer"},
+							"onBehalfOf": {Type: "string"},
+						},
+					},
+					"delete": {HTTPMethod: "DELETE", Path: "videos"},
+				},
+			},
+		},
+	}
+	newDoc := &Document{
+		Schemas: map[string]*Schema{
+			"Video":    {Properties: map[string]*Schema{"id": {Type: "string"}, "snippet": {Ref: "VideoSnippet"}}},
+			"Playlist": {},
+		},
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						HTTPMethod: "GET",
+						Path:       "videos",
+						Parameters: map[string]*Parameter{
+							"part":       {Type: "string", Required: true},
+							"maxResults": {Type: "string"},
+						},
+					},
+					"delete": {HTTPMethod: "DELETE", Path: "videos"},
+					"rate":   {HTTPMethod: "POST", Path: "videos/rate"},
+				},
+			},
+		},
+	}
+
+	d := DiffDocuments(oldDoc, newDoc)
+
+	if strings.Join(d.AddedMethods, ",") != "videos.rate" {
+		t.Errorf("AddedMethods = %v, want [videos.rate]", d.AddedMethods)
+	}
+	if len(d.RemovedMethods) != 0 {
+		t.Errorf("RemovedMethods = %v, want none", d.RemovedMethods)
+	}
+	if len(d.ChangedMethods) != 1 || d.ChangedMethods[0].Name != "videos.list" {
+		t.Fatalf("ChangedMethods = %+v, want only videos.list", d.ChangedMethods)
+	}
+	changes := strings.Join(d.ChangedMethods[0].Changes, "\n")
+	if !strings.Contains(changes, "parameter removed: onBehalfOf") {
+		t.Errorf("expected removed parameter, got:\n%s", changes)
+	}
+	if !strings.Contains(changes, "parameter changed: maxResults integer -> string") {
+		t.Errorf("expected changed parameter type, got:\n%s", changes)
+	}
+	if strings.Join(d.AddedSchemas, ",") != "Playlist" {
+		t.Errorf("AddedSchemas = %v, want [Playlist]", d.AddedSchemas)
+	}
+	if strings.Join(d.AddedProperties, ",") != "Video.snippet" || strings.Join(d.RemovedProperties, ",") != "Video.etag" {
+		t.Errorf("properties added=%v removed=%v", d.AddedProperties, d.RemovedProperties)
+	}
+
+	summary := d.String()
+	for _, want := range []string{"+ videos.rate", "~ videos.list", "- Video.etag"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary should contain %q:\n%s", want, summary)
+		}
+	}
+
+	if !DiffDocuments(oldDoc, oldDoc).Empty() {
+		t.Error("diffing a document against itself should be empty")
+	}
+}
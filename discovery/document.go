@@ -2,9 +2,11 @@
 package discovery
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 )
 
 // Document represents a Google API Discovery Document.
@@ -18,6 +20,8 @@ type Document struct {
 	RootURL           string                `json:"rootUrl"`
 	ServicePath       string                `json:"servicePath"`
 	DocumentationLink string                `json:"documentationLink"`
+	Revision          string                `json:"revision"` // Document revision date, e.g. "20240101"
+	Etag              string                `json:"etag"`     // Document etag; absent from some documents
 	Schemas           map[string]*Schema    `json:"schemas"`
 	Resources         map[string]*Resource  `json:"resources"`
 	Methods           map[string]*Method    `json:"methods"`    // Top-level methods (rare)
@@ -34,6 +38,7 @@ type Resource struct {
 type Method struct {
 	ID                    string                `json:"id"`
 	Path                  string                `json:"path"`
+	FlatPath              string                `json:"flatPath"` // Path with one variable per segment, e.g. "v1/projects/{projectsId}/topics"
 	HTTPMethod            string                `json:"httpMethod"`
 	Description           string                `json:"description"`
 	Parameters            map[string]*Parameter `json:"parameters"`
@@ -43,22 +48,37 @@ type Method struct {
 	Scopes                []string              `json:"scopes"`
 	MediaUpload           *MediaUpload          `json:"mediaUpload"`
 	SupportsMediaDownload bool                  `json:"supportsMediaDownload"`
+	EtagRequired          bool                  `json:"etagRequired"` // Whether an ETag must be sent with the request
+	QuotaCost             *int                  `json:"quotaCost"`    // Quota cost hint; an extension some documents carry, nil if absent
+	ParameterKeys         []string              `json:"-"`            // Parameter names in document order
 }
 
 // Parameter represents a method parameter.
 type Parameter struct {
-	Type             string   `json:"type"`
-	Description      string   `json:"description"`
-	Required         bool     `json:"required"`
-	Location         string   `json:"location"` // "path" or "query"
-	Repeated         bool     `json:"repeated"`
-	Default          string   `json:"default"`
-	Enum             []string `json:"enum"`
-	EnumDescriptions []string `json:"enumDescriptions"`
-	Minimum          string   `json:"minimum"`
-	Maximum          string   `json:"maximum"`
-	Format           string   `json:"format"` // e.g., "int64", "uint64"
-	Pattern          string   `json:"pattern"`
+	Type             string       `json:"type"`
+	Description      string       `json:"description"`
+	Required         bool         `json:"required"`
+	Location         string       `json:"location"` // "path" or "query"
+	Repeated         bool         `json:"repeated"`
+	Default          string       `json:"default"`
+	Enum             EnumValues   `json:"enum"`
+	EnumDescriptions []string     `json:"enumDescriptions"`
+	Minimum          string       `json:"minimum"`
+	Maximum          string       `json:"maximum"`
+	Format           string       `json:"format"` // e.g., "int64", "uint64"
+	Pattern          string       `json:"pattern"`
+	Annotations      *Annotations `json:"annotations"` // annotations.required lists IDs of methods requiring the parameter
+}
+
+// RequiredFor reports whether the parameter is required for the method with the
+// given ID, either through its "required" flag or because its annotations.required
+// lists the method's ID. The two sources are ORed: an annotation can make a
+// parameter required but never optional.
+func (p *Parameter) RequiredFor(methodID string) bool {
+	if p.Required {
+		return true
+	}
+	return p.Annotations != nil && methodID != "" && indexOf(p.Annotations.Required, methodID) != -1
 }
 
 // Schema represents a JSON Schema in the Discovery Document.
@@ -72,14 +92,85 @@ type Schema struct {
 	AdditionalProperties *Schema            `json:"additionalProperties"` // For maps
 	Ref                  string             `json:"$ref"`
 	Default              string             `json:"default"`
-	Enum                 []string           `json:"enum"`
+	Enum                 EnumValues         `json:"enum"`
 	EnumDescriptions     []string           `json:"enumDescriptions"`
 	Required             bool               `json:"required"` // When used as property
 	ReadOnly             bool               `json:"readOnly"`
 	Annotations          *Annotations       `json:"annotations"`
+	PropertyKeys         []string           `json:"-"`             // Property names in document order
+	MinItems             json.Number        `json:"minItems"`      // For arrays
+	MaxItems             json.Number        `json:"maxItems"`      // For arrays
+	MinLength            json.Number        `json:"minLength"`     // For strings
+	MaxLength            json.Number        `json:"maxLength"`     // For strings
+	MinProperties        json.Number        `json:"minProperties"` // For maps
+	MaxProperties        json.Number        `json:"maxProperties"` // For maps
+}
+
+// EnumValues holds enum values as strings. Discovery Documents occasionally list
+// numeric (or boolean) enum values; they are kept in their JSON text form.
+type EnumValues []string
+
+// UnmarshalJSON decodes an array of strings, numbers or booleans.
+func (e *EnumValues) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*e = nil
+		return nil
+	}
+	values := make(EnumValues, 0, len(raw))
+	for _, r := range raw {
+		var s string
+		if err := json.Unmarshal(r, &s); err == nil {
+			values = append(values, s)
+			continue
+		}
+		var v any
+		dec := json.NewDecoder(bytes.NewReader(r))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		switch v := v.(type) {
+		case json.Number:
+			values = append(values, v.String())
+		case bool:
+			values = append(values, fmt.Sprint(v))
+		default:
+			return fmt.Errorf("unsupported enum value %s", r)
+		}
+	}
+	*e = values
+	return nil
 }
 
-// Annotations contains metadata about schema fields.
+// ForType returns the enum values for a JSON Schema of the given Discovery type:
+// json.Number values for "integer" and "number", so they match the type, and
+// the strings otherwise. Numeric types whose values aren't all numbers keep
+// them as strings.
+func (e EnumValues) ForType(typ string) any {
+	if typ != "integer" && typ != "number" {
+		return []string(e)
+	}
+	values := make([]any, len(e))
+	for i, v := range e {
+		if !isJSONNumber(v) {
+			return []string(e)
+		}
+		values[i] = json.Number(v)
+	}
+	return values
+}
+
+// isJSONNumber reports whether s is a JSON number literal, which is also a
+// valid Go constant.
+func isJSONNumber(s string) bool {
+	return s != "" && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) && json.Valid([]byte(s))
+}
+
+// Annotations contains metadata about schema fields and method parameters.
 type Annotations struct {
 	Required []string `json:"required"`
 }
@@ -111,6 +202,75 @@ func Parse(data []byte) (*Document, error) {
 	return &doc, nil
 }
 
+// UnmarshalJSON decodes a method and records the document order of its parameters.
+func (m *Method) UnmarshalJSON(data []byte) error {
+	type method Method
+	if err := json.Unmarshal(data, (*method)(m)); err != nil {
+		return err
+	}
+	var raw struct {
+		Parameters json.RawMessage `json:"parameters"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	keys, err := objectKeys(raw.Parameters)
+	if err != nil {
+		return err
+	}
+	m.ParameterKeys = keys
+	return nil
+}
+
+// UnmarshalJSON decodes a schema and records the document order of its properties.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type schema Schema
+	if err := json.Unmarshal(data, (*schema)(s)); err != nil {
+		return err
+	}
+	var raw struct {
+		Properties json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	keys, err := objectKeys(raw.Properties)
+	if err != nil {
+		return err
+	}
+	s.PropertyKeys = keys
+	return nil
+}
+
+// objectKeys returns the keys of a JSON object in the order they appear.
+// An empty or null value yields no keys.
+func objectKeys(data json.RawMessage) ([]string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil { // opening brace
+		return nil, err
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object key %v", tok)
+		}
+		keys = append(keys, key)
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
 // AllMethods returns all methods from the document, flattened with full names.
 // e.g., "videos.list", "playlists.insert", "channels.list"
 func (d *Document) AllMethods() map[string]*Method {
@@ -139,13 +299,79 @@ func collectMethods(prefix string, r *Resource, methods map[string]*Method) {
 	}
 }
 
-// SortedMethodNames returns method names in sorted order.
+// SortedMethodNames returns method names in sorted order. Names are compared
+// segment by segment, so the methods of a resource stay together however deep
+// it is nested, even next to a sibling whose name extends it (e.g. "tags" and
+// "tags-v2").
 func (d *Document) SortedMethodNames() []string {
 	methods := d.AllMethods()
 	names := make([]string, 0, len(methods))
 	for name := range methods {
 		names = append(names, name)
 	}
-	sort.Strings(names)
+	sort.Slice(names, func(i, j int) bool {
+		return methodNameLess(names[i], names[j])
+	})
 	return names
 }
+
+// methodNameLess orders flattened method names by their dot-separated segments.
+func methodNameLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// MethodSummary is a structured overview of a single API method.
+type MethodSummary struct {
+	Name           string   // Flattened name, e.g. "videos.list"
+	ID             string   // Method ID, e.g. "youtube.videos.list"
+	HTTPMethod     string   // e.g. "GET"
+	Path           string   // e.g. "youtube/v3/videos"
+	Description    string   // Full method description
+	RequiredParams []string // Required parameter names, in parameterOrder then alphabetical order
+}
+
+// MethodSummaries returns a summary of every method, sorted by name.
+func (d *Document) MethodSummaries() []MethodSummary {
+	methods := d.AllMethods()
+	summaries := make([]MethodSummary, 0, len(methods))
+	for _, name := range d.SortedMethodNames() {
+		m := methods[name]
+		summaries = append(summaries, MethodSummary{
+			Name:           name,
+			ID:             m.ID,
+			HTTPMethod:     m.HTTPMethod,
+			Path:           m.Path,
+			Description:    m.Description,
+			RequiredParams: requiredParams(m),
+		})
+	}
+	return summaries
+}
+
+// requiredParams returns the method's required parameter names (see
+// Parameter.RequiredFor), ordered by parameterOrder first and alphabetically
+// after that.
+func requiredParams(m *Method) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range m.ParameterOrder {
+		if p, ok := m.Parameters[name]; ok && p.RequiredFor(m.ID) && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	var rest []string
+	for name, p := range m.Parameters {
+		if p.RequiredFor(m.ID) && !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
@@ -0,0 +1,236 @@
+package discovery
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseRecordsKeyOrder(t *testing.T) {
+	doc, err := Parse([]byte(`{
+		"name": "test",
+		"schemas": {
+			"Video": {
+				"type": "object",
+				"properties": {
+					"snippet": {"type": "string"},
+					"id": {"type": "string"},
+					"status": {"type": "object", "properties": {"zeta": {"type": "string"}, "alpha": {"type": "string"}}}
+				}
+			}
+		},
+		"resources": {
+			"videos": {
+				"methods": {
+					"list": {
+						"id": "videos.list",
+						"parameters": {
+							"pageToken": {"type": "string"},
+							"part": {"type": "string", "required": true},
+							"maxResults": {"type": "integer"}
+						}
+					}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	video := doc.Schemas["Video"]
+	if got := strings.Join(video.PropertyKeys, ","); got != "snippet,id,status" {
+		t.Errorf("Video.PropertyKeys = %q, want snippet,id,status", got)
+	}
+	if got := strings.Join(video.Properties["status"].PropertyKeys, ","); got != "zeta,alpha" {
+		t.Errorf("nested PropertyKeys = %q, want zeta,alpha", got)
+	}
+	list := doc.Resources["videos"].Methods["list"]
+	if got := strings.Join(list.ParameterKeys, ","); got != "pageToken,part,maxResults" {
+		t.Errorf("ParameterKeys = %q, want pageToken,part,maxResults", got)
+	}
+	if list.Parameters["part"] == nil || !list.Parameters["part"].Required {
+		t.Error("parameters should still be decoded normally")
+	}
+}
+
+func TestAllMethodsDeepNesting(t *testing.T) {
+	leaf := func(id string) *Method { return &Method{ID: id} }
+	doc := &Document{
+		Methods: map[string]*Method{"getVersion": leaf("tagmanager.getVersion")},
+		Resources: map[string]*Resource{
+			"accounts": {
+				Methods: map[string]*Method{"list": leaf("tagmanager.accounts.list")},
+				Resources: map[string]*Resource{
+					"containers": {
+						Methods: map[string]*Method{"get": leaf("tagmanager.accounts.containers.get")},
+						Resources: map[string]*Resource{
+							"workspaces": {
+								Resources: map[string]*Resource{
+									"tags": {Methods: map[string]*Method{
+										"create": leaf("tagmanager.accounts.containers.workspaces.tags.create"),
+										"list":   leaf("tagmanager.accounts.containers.workspaces.tags.list"),
+									}},
+									"tags-v2": {Methods: map[string]*Method{
+										"get": leaf("tagmanager.accounts.containers.workspaces.tags-v2.get"),
+									}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	methods := doc.AllMethods()
+	for name, m := range methods {
+		if want := "tagmanager." + name; m.ID != want {
+			t.Errorf("AllMethods()[%q] has ID %q, want %q", name, m.ID, want)
+		}
+	}
+	want := []string{
+		"accounts.containers.get",
+		"accounts.containers.workspaces.tags.create",
+		"accounts.containers.workspaces.tags.list",
+		"accounts.containers.workspaces.tags-v2.get",
+		"accounts.list",
+		"getVersion",
+	}
+	if got := doc.SortedMethodNames(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("SortedMethodNames() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(methods) != len(want) {
+		t.Errorf("AllMethods() returned %d methods, want %d", len(methods), len(want))
+	}
+}
+
+func TestMethodSummaries(t *testing.T) {
+	doc := &Document{
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						ID:          "youtube.videos.list",
+						HTTPMethod:  "GET",
+						Path:        "youtube/v3/videos",
+						Description: "Retrieves a list of videos.",
+						Parameters: map[string]*Parameter{
+							"part":       {Type: "string", Required: true},
+							"maxResults": {Type: "integer"},
+							"chart":      {Type: "string", Annotations: &Annotations{Required: []string{"youtube.videos.list"}}},
+							"hl":         {Type: "string", Annotations: &Annotations{Required: []string{"youtube.videos.rate"}}},
+						},
+					},
+					"rate": {
+						ID:             "youtube.videos.rate",
+						HTTPMethod:     "POST",
+						ParameterOrder: []string{"id", "rating"},
+						Parameters: map[string]*Parameter{
+							"rating": {Type: "string", Required: true},
+							"id":     {Type: "string", Required: true},
+							"alt":    {Type: "string", Required: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	summaries := doc.MethodSummaries()
+	if len(summaries) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(summaries))
+	}
+
+	list := summaries[0]
+	if list.Name != "videos.list" || list.HTTPMethod != "GET" || list.Path != "youtube/v3/videos" {
+		t.Errorf("unexpected videos.list summary: %+v", list)
+	}
+	if strings.Join(list.RequiredParams, ",") != "chart,part" {
+		t.Errorf("videos.list required params = %v, want [chart part] (chart required by annotation)", list.RequiredParams)
+	}
+
+	rate := summaries[1]
+	if rate.Name != "videos.rate" || rate.HTTPMethod != "POST" {
+		t.Errorf("unexpected videos.rate summary: %+v", rate)
+	}
+	if strings.Join(rate.RequiredParams, ",") != "id,rating,alt" {
+		t.Errorf("videos.rate required params = %v, want [id rating alt]", rate.RequiredParams)
+	}
+}
+
+func TestParseNumericEnums(t *testing.T) {
+	doc, err := Parse([]byte(`{
+		"name": "test",
+		"resources": {
+			"videos": {
+				"methods": {
+					"list": {
+						"parameters": {
+							"quality": {"type": "integer", "format": "int32", "enum": [360, 720, 1080.5, -1]},
+							"mode": {"type": "string", "enum": ["fast", "slow"]},
+							"flag": {"type": "boolean", "enum": [true, false]}
+						}
+					}
+				}
+			}
+		},
+		"schemas": {
+			"Video": {"type": "object", "properties": {"rating": {"type": "integer", "enum": [1, "2", 3]}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	params := doc.Resources["videos"].Methods["list"].Parameters
+	for name, want := range map[string]string{
+		"quality": "360,720,1080.5,-1",
+		"mode":    "fast,slow",
+		"flag":    "true,false",
+	} {
+		if got := strings.Join(params[name].Enum, ","); got != want {
+			t.Errorf("%s enum = %q, want %q", name, got, want)
+		}
+	}
+	if got := strings.Join(doc.Schemas["Video"].Properties["rating"].Enum, ","); got != "1,2,3" {
+		t.Errorf("mixed enum = %q, want 1,2,3", got)
+	}
+
+	p := &ParamInfo{Name: "quality", Param: params["quality"]}
+	if got := p.SchemaDescription(); !strings.Contains(got, "Values: 360, 720, 1080.5, -1") {
+		t.Errorf("SchemaDescription() = %q, want numeric values listed", got)
+	}
+
+	// JSON Schemas list them as numbers, matching the integer and number types.
+	m := &MethodInfo{FullName: "videos.list", Method: doc.Resources["videos"].Methods["list"]}
+	literal := m.InputSchemaLiteral()
+	for _, want := range []string{`"enum": []any{360, 720, 1080.5, -1}`, `"enum": []string{"fast", "slow"}`, `"enum": []string{"true", "false"}`} {
+		if !strings.Contains(literal, want) {
+			t.Errorf("InputSchemaLiteral() missing %s:\n%s", want, literal)
+		}
+	}
+	if got, want := p.JSONSchemaLiteral(), `Enum: []any{360, 720, 1080.5, -1}`; !strings.Contains(got, want) {
+		t.Errorf("JSONSchemaLiteral() = %s, want %s", got, want)
+	}
+	for _, tt := range []struct {
+		schema map[string]any
+		want   string
+	}{
+		{openAPIParameter("quality", params["quality"]), `"enum":[360,720,1080.5,-1]`},
+		{openAPISchema(doc.Schemas["Video"].Properties["rating"], nil), `"enum":[1,2,3]`},
+		{openAPIParameter("mode", params["mode"]), `"enum":["fast","slow"]`},
+	} {
+		data, err := json.Marshal(tt.schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), tt.want) {
+			t.Errorf("OpenAPI schema %s, want %s", data, tt.want)
+		}
+	}
+
+	if _, err := Parse([]byte(`{"schemas": {"X": {"type": "string", "enum": [{"a": 1}]}}}`)); err == nil {
+		t.Error("object enum values should be rejected")
+	}
+}
@@ -0,0 +1,180 @@
+package discovery
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnumInfo describes a generated string enum type and its constants.
+// Parameters and properties with the same set of values share one EnumInfo.
+type EnumInfo struct {
+	TypeName     string
+	Values       []string
+	Descriptions []string
+
+	fieldCounts map[string]int // Field name -> number of fields using this value set
+}
+
+// EnumConst is a single generated enum constant.
+type EnumConst struct {
+	Name        string
+	Value       string
+	Description string
+}
+
+// Constants returns the enum's constants in declaration order.
+func (e *EnumInfo) Constants() []EnumConst {
+	consts := make([]EnumConst, 0, len(e.Values))
+	used := make(map[string]bool)
+	for i, v := range e.Values {
+		name := e.TypeName + identifierFromValue(v)
+		if name == e.TypeName || used[name] {
+			name = e.TypeName + "Value" + strconv.Itoa(i)
+		}
+		used[name] = true
+		c := EnumConst{Name: name, Value: v}
+		if i < len(e.Descriptions) {
+			c.Description = cleanDescription(e.Descriptions[i])
+		}
+		consts = append(consts, c)
+	}
+	return consts
+}
+
+// enumRegistry deduplicates enums by their (order-independent) set of values.
+type enumRegistry struct {
+	byKey map[string]*EnumInfo
+}
+
+func newEnumRegistry() *enumRegistry {
+	return &enumRegistry{byKey: make(map[string]*EnumInfo)}
+}
+
+func enumKey(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\x00")
+}
+
+// add records that field uses a string enum with the given values.
+func (r *enumRegistry) add(field string, values, descriptions []string) {
+	key := enumKey(values)
+	e, ok := r.byKey[key]
+	if !ok {
+		e = &EnumInfo{Values: values, Descriptions: descriptions, fieldCounts: make(map[string]int)}
+		r.byKey[key] = e
+	}
+	e.fieldCounts[field]++
+}
+
+// typeFor returns the generated type name for a string enum, or "" if there is none.
+func (r *enumRegistry) typeFor(typ string, values []string) string {
+	if r == nil || typ != "string" || len(values) == 0 {
+		return ""
+	}
+	if e, ok := r.byKey[enumKey(values)]; ok {
+		return e.TypeName
+	}
+	return ""
+}
+
+// finalize names every enum after its most common field name (ties broken
+// alphabetically), avoiding the reserved type names, and returns the enums sorted
+// by type name.
+func (r *enumRegistry) finalize(reserved map[string]bool) []*EnumInfo {
+	keys := make([]string, 0, len(r.byKey))
+	for k := range r.byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	used := make(map[string]bool)
+	for name := range reserved {
+		used[name] = true
+	}
+	enums := make([]*EnumInfo, 0, len(keys))
+	for _, k := range keys {
+		e := r.byKey[k]
+		base := exportedName(e.mostCommonField())
+		name := base
+		for i := 2; used[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		used[name] = true
+		e.TypeName = name
+		enums = append(enums, e)
+	}
+	sort.Slice(enums, func(i, j int) bool { return enums[i].TypeName < enums[j].TypeName })
+	return enums
+}
+
+func (e *EnumInfo) mostCommonField() string {
+	var best string
+	bestCount := 0
+	for field, n := range e.fieldCounts {
+		if n > bestCount || (n == bestCount && field < best) {
+			best, bestCount = field, n
+		}
+	}
+	return best
+}
+
+// collectEnums registers every string enum used by the methods' parameters and
+// the schemas' properties. Property enums are named after their containing
+// schema as well as the field (e.g. VideoStatusPrivacyStatus), so that same-named
+// fields of different schemas don't read as one type; parameter enums use the
+// bare field name.
+func collectEnums(methods []*MethodInfo, schemas []*SchemaInfo, allSchemas map[string]*Schema) *enumRegistry {
+	r := newEnumRegistry()
+	for _, m := range methods {
+		for _, p := range m.SortedParams() {
+			if p.Param.Type == "string" && len(p.Param.Enum) > 0 {
+				r.add(p.Name, p.Param.Enum, p.Param.EnumDescriptions)
+			}
+		}
+	}
+	for _, s := range schemas {
+		scope := schemaTypeName(s.Name, s.Options)
+		for _, p := range s.SortedProperties() {
+			r.addSchema(scope+exportedName(p.Name), p.Property, allSchemas, s.SchemaIDs)
+		}
+	}
+	return r
+}
+
+// addSchema registers enums on a property schema, its array items and map values,
+// and on scalar wrapper schemas it references.
+func (r *enumRegistry) addSchema(field string, schema *Schema, allSchemas map[string]*Schema, ids map[string]string) {
+	if schema.Ref != "" {
+		if ref, ok := allSchemas[schemaKey(schema.Ref, ids)]; ok && isScalarSchema(ref) {
+			r.addSchema(field, ref, allSchemas, ids)
+		}
+		return
+	}
+	if schema.Type == "string" && len(schema.Enum) > 0 {
+		r.add(field, schema.Enum, schema.EnumDescriptions)
+	}
+	if schema.Items != nil {
+		r.addSchema(field, schema.Items, allSchemas, ids)
+	}
+	if schema.AdditionalProperties != nil {
+		r.addSchema(field, schema.AdditionalProperties, allSchemas, ids)
+	}
+}
+
+// identifierFromValue converts an enum value such as "mostPopular",
+// "CHART_UNSPECIFIED" or "video/mp4" into an exported identifier fragment.
+func identifierFromValue(v string) string {
+	words := strings.FieldsFunc(v, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
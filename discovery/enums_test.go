@@ -0,0 +1,117 @@
+package discovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateMCPToolsSharedEnums(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Title:   "Test API",
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						ID: "videos.list",
+						Parameters: map[string]*Parameter{
+							"order": {Type: "string", Enum: []string{"date", "rating"}},
+						},
+					},
+				},
+			},
+			"playlists": {
+				Methods: map[string]*Method{
+					"list": {
+						ID: "playlists.list",
+						Parameters: map[string]*Parameter{
+							"order":    {Type: "string", Enum: []string{"rating", "date"}},
+							"sortMode": {Type: "string", Enum: []string{"date", "rating"}},
+							"filter":   {Type: "string", Enum: []string{"all", "mine"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateEnums: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+
+	// Three fields share {date, rating}; "order" is the most common name.
+	if strings.Count(code, "type Order string") != 1 {
+		t.Errorf("expected exactly one shared Order enum type\nGenerated code:\n%s", code)
+	}
+	if strings.Contains(code, "type SortMode string") {
+		t.Error("sortMode has the same values as order and should reuse the Order type")
+	}
+	if !strings.Contains(code, "type Filter string") {
+		t.Error("filter has distinct values and should get its own type")
+	}
+	if !containsFieldType(code, "SortMode", "Order") || !containsFieldType(code, "Order", "Order") {
+		t.Errorf("fields with identical enums should reference the shared type\nGenerated code:\n%s", code)
+	}
+	if !containsFieldType(code, "OrderRating", `Order = "rating"`) {
+		t.Errorf("expected OrderRating constant\nGenerated code:\n%s", code)
+	}
+}
+
+func TestGenerateMCPToolsSchemaScopedEnums(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Title:   "Test API",
+		Schemas: map[string]*Schema{
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{
+				"status": {Type: "string", Enum: []string{"public", "private"}},
+			}},
+			"Channel": {ID: "Channel", Type: "object", Properties: map[string]*Schema{
+				"status": {Type: "string", Enum: []string{"active", "suspended"}},
+			}},
+			"Playlist": {ID: "Playlist", Type: "object", Properties: map[string]*Schema{
+				"status": {Type: "string", Enum: []string{"active", "suspended"}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateEnums: true, GenerateSchema: true, AllSchemas: true, SchemaOnly: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+
+	if !strings.Contains(code, "type VideoStatus string") {
+		t.Errorf("expected Video's status enum to be scoped as VideoStatus\nGenerated code:\n%s", code)
+	}
+	if !strings.Contains(code, "type ChannelStatus string") {
+		t.Errorf("expected Channel's status enum to be scoped as ChannelStatus\nGenerated code:\n%s", code)
+	}
+	if strings.Contains(code, "type Status string") || strings.Contains(code, "type PlaylistStatus string") {
+		t.Errorf("unexpected enum type; Playlist's identical values should reuse ChannelStatus\nGenerated code:\n%s", code)
+	}
+	if !containsFieldType(code, "Status", "VideoStatus `json") {
+		t.Errorf("expected Video.Status to use VideoStatus\nGenerated code:\n%s", code)
+	}
+	if strings.Count(code, "ChannelStatus `json") != 2 {
+		t.Errorf("expected Channel.Status and Playlist.Status to share ChannelStatus\nGenerated code:\n%s", code)
+	}
+}
+
+func TestIdentifierFromValue(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"mostPopular", "MostPopular"},
+		{"CHART_UNSPECIFIED", "CHARTUNSPECIFIED"},
+		{"video/mp4", "VideoMp4"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := identifierFromValue(tt.input); got != tt.want {
+			t.Errorf("identifierFromValue(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
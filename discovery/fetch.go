@@ -1,26 +1,121 @@
 package discovery
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
 )
 
-const discoveryBaseURL = "https://www.googleapis.com/discovery/v1/apis"
+// discoveryBaseURL is the root of Google's API Discovery Service.
+// It is a variable so tests can point it at a fake server.
+var discoveryBaseURL = "https://www.googleapis.com/discovery/v1/apis"
+
+// defaultHTTPClient is the client used unless SetHTTPClient replaces it. Its
+// transport sets Proxy explicitly, so HTTP_PROXY, HTTPS_PROXY and NO_PROXY are
+// honored whatever the state of http.DefaultTransport.
+var defaultHTTPClient = newDefaultHTTPClient()
+
+// httpClient is the client used for all Discovery Service requests.
+var httpClient = defaultHTTPClient
+
+// newDefaultHTTPClient returns a client with http.DefaultTransport's dial and
+// TLS settings that routes requests through the proxy from the environment.
+func newDefaultHTTPClient() *http.Client {
+	transport := &http.Transport{}
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = t.Clone()
+	}
+	transport.Proxy = http.ProxyFromEnvironment
+	return &http.Client{Transport: transport}
+}
+
+// SetHTTPClient sets the client used by Fetch, FetchFormat, FetchURL, FetchMany and
+// ListAPIs, e.g. one wrapping an oauth2 transport for private discovery endpoints.
+// A nil client restores the default client, which honors the proxy environment
+// variables.
+func SetHTTPClient(c *http.Client) {
+	if c == nil {
+		c = defaultHTTPClient
+	}
+	httpClient = c
+}
+
+// DefaultFormat is the discovery format Fetch requests.
+const DefaultFormat = "rest"
+
+// discoveryFormats are the discovery format suffixes FetchFormat accepts.
+var discoveryFormats = []string{"rest", "rpc"}
 
 // Fetch downloads a Discovery Document from Google's API.
 // api is the API name (e.g., "youtube")
 // version is the API version (e.g., "v3")
 func Fetch(api, version string) (*Document, error) {
-	url := fmt.Sprintf("%s/%s/%s/rest", discoveryBaseURL, api, version)
-	return FetchURL(url)
+	return FetchFormat(api, version, DefaultFormat)
+}
+
+// FetchFormat downloads a Discovery Document in the given format ("rest" or "rpc"),
+// from {api}/{version}/{format} under the Discovery Service root.
+func FetchFormat(api, version, format string) (*Document, error) {
+	url, err := DiscoveryFormatURL(api, version, format)
+	if err != nil {
+		return nil, err
+	}
+	return fetchURL(context.Background(), url)
+}
+
+// DiscoveryURL returns the URL Fetch downloads the Discovery Document from,
+// e.g. "https://www.googleapis.com/discovery/v1/apis/youtube/v3/rest".
+func DiscoveryURL(api, version string) string {
+	return discoveryURL(api, version, DefaultFormat)
+}
+
+// DiscoveryFormatURL returns the URL FetchFormat downloads the Discovery Document
+// from. It fails for formats FetchFormat does not accept.
+func DiscoveryFormatURL(api, version, format string) (string, error) {
+	if indexOf(discoveryFormats, format) == -1 {
+		return "", fmt.Errorf("unsupported discovery format %q (want one of %s)", format, strings.Join(discoveryFormats, ", "))
+	}
+	return discoveryURL(api, version, format), nil
+}
+
+func discoveryURL(api, version, format string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", discoveryBaseURL, api, version, format)
 }
 
 // FetchURL downloads a Discovery Document from a URL.
 func FetchURL(url string) (*Document, error) {
-	resp, err := http.Get(url) //nolint:gosec // URL is constructed from user input, but this is a CLI tool
+	return fetchURL(context.Background(), url)
+}
+
+// FetchURLWithTransport downloads a Discovery Document from a URL, sending the
+// request through rt instead of the configured client. This lets callers add
+// credentials, e.g. a bearer token for an endpoint behind IAP.
+func FetchURLWithTransport(rt http.RoundTripper, url string) (*Document, error) {
+	return fetchURLWithClient(context.Background(), &http.Client{Transport: rt}, url)
+}
+
+func fetchURL(ctx context.Context, url string) (*Document, error) {
+	return fetchURLWithClient(ctx, httpClient, url)
+}
+
+func fetchURLWithClient(ctx context.Context, client *http.Client, url string) (*Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+	resp, err := client.Do(req) //nolint:gosec // URL is constructed from user input, but this is a CLI tool
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
 	}
@@ -39,36 +134,250 @@ func FetchURL(url string) (*Document, error) {
 	return Parse(data)
 }
 
-// LoadFile loads a Discovery Document from a local file.
+// APISpec identifies a Discovery Document by API name and version.
+type APISpec struct {
+	Name    string // e.g., "youtube"
+	Version string // e.g., "v3"
+}
+
+// Key returns the spec in Discovery ID form (e.g., "youtube:v3").
+func (s APISpec) Key() string {
+	return s.Name + ":" + s.Version
+}
+
+// FetchMany downloads several Discovery Documents concurrently, running at most
+// concurrency fetches at a time (values below 1 are treated as 1).
+// The result is keyed by APISpec.Key. Documents that were fetched successfully are
+// returned even if others failed; the returned error joins all individual failures.
+func FetchMany(ctx context.Context, specs []APISpec, concurrency int) (map[string]*Document, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		docs = make(map[string]*Document, len(specs))
+		errs []error
+		sem  = make(chan struct{}, concurrency)
+	)
+
+	for _, spec := range specs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", spec.Key(), ctx.Err()))
+				mu.Unlock()
+				return
+			}
+			defer func() { <-sem }()
+
+			doc, err := fetchURL(ctx, discoveryURL(spec.Name, spec.Version, DefaultFormat))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", spec.Key(), err))
+				return
+			}
+			docs[spec.Key()] = doc
+		}()
+	}
+	wg.Wait()
+
+	return docs, errors.Join(errs...)
+}
+
+// LoadFile loads a Discovery Document from a local file. Gzip-compressed files
+// (e.g. "youtube-v3.json.gz") are detected by their header and decompressed.
 func LoadFile(path string) (*Document, error) {
 	data, err := os.ReadFile(path) //nolint:gosec // Path is from user input, but this is a CLI tool
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	if bytes.HasPrefix(data, gzipMagic) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress file: %w", err)
+		}
+		defer func() { _ = zr.Close() }()
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("failed to decompress file: %w", err)
+		}
+	}
 	return Parse(data)
 }
 
-// ListAPIs returns a list of all available Google APIs.
+// gzipMagic is the header every gzip stream starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ListAPIs returns a list of all available Google APIs. When a cache is
+// configured with SetCache, a fresh cached listing is returned without a request;
+// a cached listing that no longer parses is fetched again.
 func ListAPIs() ([]APIInfo, error) {
-	resp, err := http.Get(discoveryBaseURL)
+	if data, ok := readCache(discoveryBaseURL); ok {
+		if apis, err := parseAPIList(data); err == nil {
+			return apis, nil
+		}
+	}
+
+	data, err := fetchDirectory()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list APIs: %w", err)
+		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	apis, err := parseAPIList(data)
+	if err != nil {
+		return nil, err
+	}
+	_ = writeCache(discoveryBaseURL, data) // The listing is still usable without the cache
+	return apis, nil
+}
+
+// LoadAPIListFile loads a saved copy of the API directory listing (the JSON
+// served at the Discovery Service root), e.g. a snapshot checked in for offline use.
+func LoadAPIListFile(path string) ([]APIInfo, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // Path is from user input, but this is a CLI tool
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return parseAPIList(data)
+}
 
+func parseAPIList(data []byte) ([]APIInfo, error) {
 	var result struct {
-		Items []APIInfo `json:"items"`
+		Items []struct {
+			APIInfo
+			Labels []string `json:"labels"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse API list: %w", err)
 	}
+	apis := make([]APIInfo, len(result.Items))
+	for i, item := range result.Items {
+		apis[i] = item.APIInfo
+		apis[i].Deprecated = isDeprecated(item.Labels, item.Description)
+	}
+	return apis, nil
+}
+
+// fetchDirectory downloads the raw API directory listing.
+func fetchDirectory() ([]byte, error) {
+	resp, err := httpClient.Get(discoveryBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list APIs: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read API list: %w", err)
 	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list APIs: %s\n%s", resp.Status, data)
+	}
+	return data, nil
+}
 
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse API list: %w", err)
+// PreferredVersion returns the preferred version of api according to the API
+// directory (see ListAPIs), or its only version if none is marked preferred.
+func PreferredVersion(api string) (string, error) {
+	apis, err := ListAPIs()
+	if err != nil {
+		return "", err
+	}
+	versions := GroupAPIs(apis)[api]
+	switch {
+	case len(versions) == 0:
+		return "", fmt.Errorf("API %q not found in the directory", api)
+	case versions[0].Preferred || len(versions) == 1:
+		return versions[0].Version, nil
+	default:
+		return "", fmt.Errorf("API %q has no preferred version; pick one of %s", api, joinVersions(versions))
+	}
+}
+
+func joinVersions(apis []APIInfo) string {
+	versions := make([]string, len(apis))
+	for i, api := range apis {
+		versions[i] = api.Version
+	}
+	return strings.Join(versions, ", ")
+}
+
+// GroupAPIs groups an API list by name. Each group lists the preferred version
+// first, followed by the others sorted by version (see versionLess).
+func GroupAPIs(apis []APIInfo) map[string][]APIInfo {
+	groups := make(map[string][]APIInfo)
+	for _, api := range apis {
+		groups[api.Name] = append(groups[api.Name], api)
+	}
+	for _, versions := range groups {
+		sort.SliceStable(versions, func(i, j int) bool {
+			if versions[i].Preferred != versions[j].Preferred {
+				return versions[i].Preferred
+			}
+			return versionLess(versions[i].Version, versions[j].Version)
+		})
+	}
+	return groups
+}
+
+// versionLess reports whether API version a sorts before b. Versions compare
+// as runs of digits and letters: numbers numerically (v2 < v10), words
+// alphabetically (v1alpha < v1beta), and a pre-release sorts before the release
+// it leads up to (v1beta2 < v1 < v1p1beta1 < v2).
+func versionLess(a, b string) bool {
+	x, y := versionParts(a), versionParts(b)
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] == y[i] {
+			continue
+		}
+		nx, errx := strconv.Atoi(x[i])
+		ny, erry := strconv.Atoi(y[i])
+		if errx == nil && erry == nil {
+			return nx < ny
+		}
+		if isPreRelease(x[i]) != isPreRelease(y[i]) {
+			return isPreRelease(x[i])
+		}
+		return x[i] < y[i]
 	}
-	return result.Items, nil
+	switch {
+	case len(x) < len(y):
+		return !isPreRelease(y[len(x)])
+	case len(x) > len(y):
+		return isPreRelease(x[len(y)])
+	}
+	return false
+}
+
+// versionParts splits a version into its runs of digits and of letters,
+// dropping separators: "v1beta2" becomes [v 1 beta 2].
+func versionParts(version string) []string {
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+	var parts []string
+	for _, field := range strings.FieldsFunc(version, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		start := 0
+		for i := 1; i < len(field); i++ {
+			if isDigit(field[i]) != isDigit(field[i-1]) {
+				parts = append(parts, field[start:i])
+				start = i
+			}
+		}
+		parts = append(parts, field[start:])
+	}
+	return parts
+}
+
+// isPreRelease reports whether a version part marks a pre-release.
+func isPreRelease(part string) bool {
+	return part == "alpha" || part == "beta"
 }
 
 // APIInfo contains basic information about an available API.
@@ -80,4 +389,27 @@ type APIInfo struct {
 	DiscoveryRestURL  string `json:"discoveryRestUrl"`
 	DocumentationLink string `json:"documentationLink"`
 	Preferred         bool   `json:"preferred"`
+	Deprecated        bool   `json:"-"` // Set from the entry's labels and description, see isDeprecated
+}
+
+// isDeprecated reports whether a directory entry marks its API version as
+// deprecated, either with a "deprecated" label or with a description starting
+// with "Deprecated" (optionally bracketed, as in "[Deprecated] ...").
+func isDeprecated(labels []string, description string) bool {
+	if slices.Contains(labels, "deprecated") {
+		return true
+	}
+	desc := strings.TrimLeft(description, " [(")
+	return len(desc) >= len("deprecated") && strings.EqualFold(desc[:len("deprecated")], "deprecated")
+}
+
+// WithoutDeprecated returns the APIs of apis that are not deprecated, in order.
+func WithoutDeprecated(apis []APIInfo) []APIInfo {
+	var kept []APIInfo
+	for _, api := range apis {
+		if !api.Deprecated {
+			kept = append(kept, api)
+		}
+	}
+	return kept
 }
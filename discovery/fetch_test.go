@@ -0,0 +1,406 @@
+package discovery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// withDiscoveryServer points discoveryBaseURL at a fake server for the duration of the test.
+func withDiscoveryServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	orig := discoveryBaseURL
+	discoveryBaseURL = srv.URL
+	t.Cleanup(func() {
+		discoveryBaseURL = orig
+		srv.Close()
+	})
+	return srv
+}
+
+func TestFetchMany(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	withDiscoveryServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		// Path: /{api}/{version}/rest
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) != 3 || parts[0] == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"id":"%s:%s","name":%q,"version":%q}`, parts[0], parts[1], parts[0], parts[1])
+	}))
+
+	specs := []APISpec{
+		{Name: "youtube", Version: "v3"},
+		{Name: "drive", Version: "v3"},
+		{Name: "gmail", Version: "v1"},
+		{Name: "calendar", Version: "v3"},
+		{Name: "sheets", Version: "v4"},
+	}
+
+	docs, err := FetchMany(context.Background(), specs, 2)
+	if err != nil {
+		t.Fatalf("FetchMany failed: %v", err)
+	}
+	if len(docs) != len(specs) {
+		t.Fatalf("got %d documents, want %d", len(docs), len(specs))
+	}
+	for _, spec := range specs {
+		doc, ok := docs[spec.Key()]
+		if !ok {
+			t.Errorf("missing document for %s", spec.Key())
+			continue
+		}
+		if doc.ID != spec.Key() {
+			t.Errorf("document for %s has ID %q", spec.Key(), doc.ID)
+		}
+	}
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("max concurrent fetches = %d, want <= 2", got)
+	}
+
+	// Failures are aggregated while successful documents are still returned.
+	docs, err = FetchMany(context.Background(), []APISpec{
+		{Name: "youtube", Version: "v3"},
+		{Name: "missing", Version: "v1"},
+	}, 4)
+	if err == nil || !strings.Contains(err.Error(), "missing:v1") {
+		t.Errorf("expected error naming missing:v1, got %v", err)
+	}
+	if _, ok := docs["youtube:v3"]; !ok {
+		t.Error("successful document should be returned alongside errors")
+	}
+}
+
+func TestFetchFormat(t *testing.T) {
+	var gotPath atomic.Value
+	withDiscoveryServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath.Store(r.URL.Path)
+		fmt.Fprint(w, `{"name": "youtube", "version": "v3"}`)
+	}))
+
+	doc, err := FetchFormat("youtube", "v3", "rpc")
+	if err != nil {
+		t.Fatalf("FetchFormat failed: %v", err)
+	}
+	if doc.Name != "youtube" {
+		t.Errorf("doc.Name = %q, want youtube", doc.Name)
+	}
+	if got := gotPath.Load(); got != "/youtube/v3/rpc" {
+		t.Errorf("requested path = %v, want /youtube/v3/rpc", got)
+	}
+
+	if _, err := Fetch("youtube", "v3"); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if got := gotPath.Load(); got != "/youtube/v3/rest" {
+		t.Errorf("Fetch requested path = %v, want /youtube/v3/rest", got)
+	}
+
+	if _, err := FetchFormat("youtube", "v3", "../admin"); err == nil || !strings.Contains(err.Error(), "unsupported discovery format") {
+		t.Errorf("FetchFormat with an unknown format = %v, want unsupported format error", err)
+	}
+}
+
+func TestLoadFileGzip(t *testing.T) {
+	const doc = `{"name": "youtube", "version": "v3", "resources": {"videos": {"methods": {"list": {"id": "youtube.videos.list"}}}}}`
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(doc)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"youtube-v3.json.gz": buf.Bytes(),
+		"youtube-v3.json":    []byte(doc),
+		"compressed.json":    buf.Bytes(), // detected by header, not extension
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("LoadFile(%s) failed: %v", name, err)
+		}
+		if got.Name != "youtube" || got.Resources["videos"].Methods["list"] == nil {
+			t.Errorf("LoadFile(%s) = %+v, want the youtube document", name, got)
+		}
+	}
+
+	path := filepath.Join(dir, "truncated.json.gz")
+	if err := os.WriteFile(path, buf.Bytes()[:buf.Len()/2], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile should fail on a truncated gzip file")
+	}
+}
+
+// authTransport adds a bearer token to every request, like an oauth2 transport would.
+type authTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
+
+func TestFetchURLWithTransport(t *testing.T) {
+	srv := withDiscoveryServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"name": "private", "version": "v1"}`)
+	}))
+	rt := &authTransport{token: "secret", base: http.DefaultTransport}
+
+	doc, err := FetchURLWithTransport(rt, srv.URL+"/private/v1/rest")
+	if err != nil {
+		t.Fatalf("FetchURLWithTransport failed: %v", err)
+	}
+	if doc.Name != "private" {
+		t.Errorf("doc.Name = %q, want private", doc.Name)
+	}
+
+	if _, err := FetchURL(srv.URL + "/private/v1/rest"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("FetchURL without credentials = %v, want 401 error", err)
+	}
+
+	SetHTTPClient(&http.Client{Transport: rt})
+	t.Cleanup(func() { SetHTTPClient(nil) })
+	if _, err := Fetch("private", "v1"); err != nil {
+		t.Errorf("Fetch with SetHTTPClient failed: %v", err)
+	}
+}
+
+// TestFetchURLProxyFromEnvironment runs itself in a child process with HTTP_PROXY
+// set, since net/http reads the proxy environment only once per process.
+func TestFetchURLProxyFromEnvironment(t *testing.T) {
+	if os.Getenv("DISCOVERY_PROXY_CHILD") != "" {
+		// .invalid never resolves, so this only succeeds through the proxy.
+		doc, err := FetchURL("http://discovery.invalid/youtube/v3/rest")
+		if err != nil {
+			t.Fatalf("FetchURL through proxy failed: %v", err)
+		}
+		if doc.Name != "youtube" {
+			t.Errorf("doc.Name = %q, want youtube", doc.Name)
+		}
+		return
+	}
+
+	var proxied atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Store(r.URL.String())
+		fmt.Fprint(w, `{"name": "youtube", "version": "v3"}`)
+	}))
+	defer proxy.Close()
+
+	var env []string
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); !strings.HasSuffix(strings.ToUpper(name), "_PROXY") {
+			env = append(env, kv)
+		}
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFetchURLProxyFromEnvironment$") //nolint:gosec // Re-runs this test binary
+	cmd.Env = append(env, "DISCOVERY_PROXY_CHILD=1", "HTTP_PROXY="+proxy.URL)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("child process failed: %v\n%s", err, out)
+	}
+	if got, want := proxied.Load(), "http://discovery.invalid/youtube/v3/rest"; got != want {
+		t.Errorf("proxy saw request for %v, want %s", got, want)
+	}
+}
+
+func TestDiscoveryURL(t *testing.T) {
+	if got, want := DiscoveryURL("youtube", "v3"), "https://www.googleapis.com/discovery/v1/apis/youtube/v3/rest"; got != want {
+		t.Errorf("DiscoveryURL = %q, want %q", got, want)
+	}
+	got, err := DiscoveryFormatURL("youtube", "v3", "rpc")
+	if err != nil {
+		t.Fatalf("DiscoveryFormatURL failed: %v", err)
+	}
+	if want := "https://www.googleapis.com/discovery/v1/apis/youtube/v3/rpc"; got != want {
+		t.Errorf("DiscoveryFormatURL = %q, want %q", got, want)
+	}
+	if _, err := DiscoveryFormatURL("youtube", "v3", "soap"); err == nil {
+		t.Error("DiscoveryFormatURL should reject unknown formats")
+	}
+}
+
+func TestGroupAPIs(t *testing.T) {
+	apis := []APIInfo{
+		{Name: "youtube", Version: "v3", Preferred: true},
+		{Name: "drive", Version: "v3", Preferred: true},
+		{Name: "drive", Version: "v2"},
+		{Name: "drive", Version: "v1"},
+		{Name: "drive", Version: "v10"},
+		{Name: "drive", Version: "v1beta"},
+	}
+
+	groups := GroupAPIs(apis)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2: %v", len(groups), groups)
+	}
+	var versions []string
+	for _, api := range groups["drive"] {
+		versions = append(versions, api.Version)
+	}
+	if got := strings.Join(versions, ","); got != "v3,v1beta,v1,v2,v10" {
+		t.Errorf("drive versions = %s, want v3,v1beta,v1,v2,v10 (preferred first, then sorted)", got)
+	}
+	if len(groups["youtube"]) != 1 || groups["youtube"][0].Version != "v3" {
+		t.Errorf("youtube group = %v, want [v3]", groups["youtube"])
+	}
+}
+
+func TestVersionLess(t *testing.T) {
+	// In ascending order.
+	versions := []string{"v1alpha", "v1beta", "v1beta1", "v1beta2", "v1", "v1.1", "v1p1beta1", "v2", "v10"}
+	for i := range versions {
+		for j := range versions {
+			if got, want := versionLess(versions[i], versions[j]), i < j; got != want {
+				t.Errorf("versionLess(%q, %q) = %v, want %v", versions[i], versions[j], got, want)
+			}
+		}
+	}
+}
+
+func TestWithoutDeprecated(t *testing.T) {
+	apis, err := LoadAPIListFile(filepath.Join("testdata", "directory_deprecated.json"))
+	if err != nil {
+		t.Fatalf("LoadAPIListFile failed: %v", err)
+	}
+	var ids []string
+	for _, api := range WithoutDeprecated(apis) {
+		ids = append(ids, api.Name+":"+api.Version)
+	}
+	if got := strings.Join(ids, ","); got != "drive:v3" {
+		t.Errorf("WithoutDeprecated kept %s, want drive:v3", got)
+	}
+	if len(apis) != 3 {
+		t.Errorf("WithoutDeprecated should not modify its input, got %d APIs", len(apis))
+	}
+}
+
+func TestListAPIsCache(t *testing.T) {
+	var hits atomic.Int32
+	withDiscoveryServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, `{"items": [
+			{"name": "drive", "version": "v2"},
+			{"name": "drive", "version": "v3", "preferred": true},
+			{"name": "youtube", "version": "v3"}
+		]}`)
+	}))
+	SetCache(t.TempDir(), time.Hour)
+	t.Cleanup(func() { SetCache("", 0) })
+
+	for i := 0; i < 2; i++ {
+		apis, err := ListAPIs()
+		if err != nil {
+			t.Fatalf("ListAPIs failed: %v", err)
+		}
+		if len(apis) != 3 {
+			t.Fatalf("got %d APIs, want 3", len(apis))
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server hit %d times, want 1 (second call served from cache)", n)
+	}
+
+	for api, want := range map[string]string{"drive": "v3", "youtube": "v3"} {
+		got, err := PreferredVersion(api)
+		if err != nil || got != want {
+			t.Errorf("PreferredVersion(%q) = %q, %v; want %q", api, got, err, want)
+		}
+	}
+	if _, err := PreferredVersion("missing"); err == nil {
+		t.Error("PreferredVersion of an unknown API should fail")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("PreferredVersion should use the cached listing, server hit %d times", n)
+	}
+
+	SetCache(t.TempDir(), 0) // Everything is stale
+	for i := 0; i < 2; i++ {
+		if _, err := ListAPIs(); err != nil {
+			t.Fatalf("ListAPIs failed: %v", err)
+		}
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("server hit %d times, want 3 once the TTL has passed", n)
+	}
+
+	path, _ := cachePath(discoveryBaseURL)
+	if err := os.WriteFile(path, []byte(`{"items": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	SetCache(filepath.Dir(path), time.Hour)
+	apis, err := ListAPIs()
+	if err != nil {
+		t.Fatalf("ListAPIs with a corrupt cache entry failed: %v", err)
+	}
+	if len(apis) != 3 || hits.Load() != 4 {
+		t.Errorf("corrupt cache entry: got %d APIs after %d hits, want 3 after 4", len(apis), hits.Load())
+	}
+	if _, err := ListAPIs(); err != nil || hits.Load() != 4 {
+		t.Errorf("refetched listing should replace the corrupt entry, server hit %d times (err %v)", hits.Load(), err)
+	}
+}
+
+func TestLoadAPIListFile(t *testing.T) {
+	apis, err := LoadAPIListFile(filepath.Join("testdata", "directory.json"))
+	if err != nil {
+		t.Fatalf("LoadAPIListFile failed: %v", err)
+	}
+	if len(apis) != 3 {
+		t.Fatalf("got %d APIs, want 3", len(apis))
+	}
+	want := APIInfo{
+		Name:              "drive",
+		Version:           "v3",
+		Title:             "Google Drive API",
+		Description:       "The Google Drive API allows clients to access resources from Google Drive.",
+		DiscoveryRestURL:  "https://www.googleapis.com/discovery/v1/apis/drive/v3/rest",
+		DocumentationLink: "https://developers.google.com/drive/",
+		Preferred:         true,
+	}
+	if apis[1] != want {
+		t.Errorf("apis[1] = %+v, want %+v", apis[1], want)
+	}
+
+	if _, err := LoadAPIListFile(filepath.Join("testdata", "missing.json")); err == nil {
+		t.Error("LoadAPIListFile of a missing file should fail")
+	}
+}
@@ -2,566 +2,2932 @@ package discovery
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go/build/constraint"
 	"go/format"
+	"go/token"
+	"path"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"unicode"
 )
 
+// registryAlias is the import name used for RegistryImportPath.
+const registryAlias = "toolregistry"
+
+// JSONTagCase values. Anything but JSONTagCaseNone changes the JSON field names
+// away from Google's wire format, so the generated types no longer round-trip
+// with the API itself; use it only for a different downstream consumer.
+const (
+	JSONTagCaseNone  = "none"
+	JSONTagCaseSnake = "snake"
+	JSONTagCaseCamel = "camel"
+)
+
+// defaultMCPImportPath is the MCP types package used by generated handlers.
+const defaultMCPImportPath = "github.com/mark3labs/mcp-go/mcp"
+
+// mcpImport returns the import spec for the MCP types package. The handlers
+// refer to it as mcp, so any path not ending in /mcp (e.g. a /v2 major version)
+// is imported under that alias.
+func mcpImport(importPath string) string {
+	if path.Base(importPath) == "mcp" {
+		return importPath
+	}
+	return "mcp " + importPath
+}
+
 // GenerateOptions configures code generation.
 type GenerateOptions struct {
-	PackageName    string   // Go package name (default: "tools")
-	Methods        []string // Specific methods to generate (empty = all)
-	Prefix         string   // Tool name prefix (e.g., "youtube_")
-	StructPrefix   string   // Struct name prefix (default: "API")
-	GenerateSchema bool     // Generate schema types (request/response bodies)
+	PackageName              string   // Go package name (default: "tools")
+	Methods                  []string // Specific methods or glob patterns (e.g. "videos.*") to generate (empty = all)
+	MethodsRegex             string   // Regular expression the flattened method names (e.g. "videos.list") must match
+	Prefix                   string   // Tool name prefix (e.g., "youtube_")
+	PrefixFromTitle          bool     // Derive the default Prefix from the slugified Title instead of Name
+	StructPrefix             string   // Struct name prefix (default: "API")
+	SchemaPrefix             string   // Prefix for every generated schema type name ; must be an exported identifier (e.g. "YT" turns Video into YTVideo)
+	GenerateSchema           bool     // Generate schema types (request/response bodies)
+	SplitReadWrite           bool     // Generate "<Name>Request" variants without readOnly fields for request bodies
+	GenerateExamples         bool     // Emit an example literal comment above each args struct
+	IncludeCommonParams      bool     // Merge document-level parameters (alt, fields, key, ...) into every method
+	GenerateInputSchema      bool     // Generate an InputSchema() method returning each args struct's JSON Schema
+	InputSchemaMap           bool     // Generate GeneratedInputSchemas, each tool's JSON Schema as a map, instead of args structs
+	GenerateEnums            bool     // Generate shared string enum types and constants
+	PreserveOrder            bool     // Emit parameters/properties in document order instead of sorted
+	BuildTags                []string // Build constraints (e.g. "integration", "!windows"), ANDed together
+	GenerateHandlers         bool     // Generate handler stubs and RegisterTools
+	GenerateDispatch         bool     // Generate ToolFuncs and its Dispatch method, a switch decoding each tool's raw arguments and calling its function
+	MCPImportPath            string   // Import path of the MCP types package (default: github.com/mark3labs/mcp-go/mcp)
+	GenerateJSONSchema       bool     // Generate a JSONSchema() method on each args struct building its invopop/jsonschema Schema without reflection
+	JSONSchemaImportPath     string   // Import path of the jsonschema package (default: github.com/invopop/jsonschema)
+	OmitSchemaTags           bool     // Emit only json tags, without jsonschema descriptions
+	ResourceSeparator        string   // Separator between resource levels in tool names (default: "_")
+	OptionalAsPointer        bool     // Make every optional scalar a pointer (*string, *int64, ...)
+	OptionalNumbersAsPointer bool     // Make optional integers and numbers pointers (*int64, *float64, ...) so 0 is not dropped as empty
+	GenerateRegistry         bool     // Generate an init() registering every tool into DefaultRegistry
+	RegistryImportPath       string   // Package providing DefaultRegistry (empty = the generated package, which then defines it)
+	GenerateScopes           bool     // Generate OAuth scope constants, a per-tool scope map and Scopes() methods
+	GenerateMarshalJSON      bool     // Generate MarshalJSON on schema types that drops nil pointers and zero structs
+	GenerateAssertions       bool     // Generate a compile-time check referencing every tool's args type
+	RawMessageForAny         bool     // Use json.RawMessage instead of any/map[string]any for freeform properties
+	GenerateTests            bool     // Also emit tools_test.go round-tripping every generated struct through JSON
+	SchemaOnly               bool     // Emit only the schema types, without tool args or tool definitions (implies GenerateSchema)
+	AllSchemas               bool     // With GenerateSchema, emit every schema in the document, not only those the methods reference
+	RootSchemas              []string // Emit only these schemas and those they transitively reference, ignoring methods (implies SchemaOnly)
+	GenericListResponse      bool     // Alias {items, nextPageToken} list responses to a generic ListResponse[T], or embed it when they have more fields
+	JSONTagCase              string   // Rename json tags: "none" (default, Google wire names), "snake" or "camel"
+	FieldComments            bool     // Also emit each field's description as a wrapped comment above the field
+	AliasDuplicates          bool     // Emit schemas whose fields match an earlier schema as type aliases of it
+	ByteAsBytes              bool     // Use []byte for base64 (type string, format byte) schema properties
+	GenerateFieldMask        bool     // Generate FieldMask() on request bodies of PATCH and updateMask methods
+	GenerateEnumValues       bool     // Generate GeneratedEnumValues, the allowed values of every enum field
+	ToolsJSON                bool     // Also emit tools.json mapping each tool to its args struct, HTTP method and path
+	SeparateSchemaFile       bool     // With GenerateFiles, put the schema and enum types in schemas.go instead of tools.go
+	GenerateDescribe         bool     // Generate a Describe() method on args structs returning the tool description
+	DescribeFull             bool     // With GenerateDescribe, return the full description instead of the 200-character one
+	EscapeMarkdown           bool     // Backslash-escape Markdown syntax in the tool descriptions given to MCP hosts (Go comments stay raw)
+	ValidateTags             bool     // Add go-playground/validator validate tags (required, oneof, min/max)
+	Subpackage               string   // GenerateFiles only: write the code to this subdirectory and package, re-exported from PackageName
+	SubpackageImport         string   // Import path of Subpackage, required with it
+	Strict                   bool     // Fail on the DocumentProblems of a malformed document instead of ignoring them
+	GenerateHTTPInfo         bool     // Generate HTTPMethod(), PathTemplate() and FlatPathTemplate() methods on args structs
+	OmitToolDefinitions      bool     // Skip the GeneratedToolDefinitions description map
+	GenerateURLValues        bool     // Generate a ToURLValues() method on args structs encoding the query parameters
+	MaxFieldDescriptionLen   int      // Truncate parameter and property descriptions in jsonschema tags to this many bytes (0 = no limit)
+	NoOmitEmpty              bool     // Drop omitempty from optional schema fields, so zero values are sent as such (args structs keep it: it marks optional inputs)
+	GenerateCheckRequired    bool     // Generate CheckRequired, reporting required arguments missing from raw map[string]any tool arguments
+	GoVersion                string   // Oldest Go release the generated code must build with, e.g. "1.17" (empty = latest); before 1.18 any is spelled interface{} and generic list responses are skipped
 }
 
 // GenerateMCPTools generates Go code for MCP tools from a Discovery Document.
+// Output is deterministic: the same document and options produce byte-identical
+// code regardless of the Go version that built the generator.
 func GenerateMCPTools(doc *Document, opts GenerateOptions) (string, error) {
+	data, err := newTemplateData(doc, opts)
+	if err != nil {
+		return "", err
+	}
+	if data.GenerateRegistry && data.RegistryQualifier == "" {
+		// There is no registry.go to define DefaultRegistry in a single file.
+		data.RegistryTypes = true
+		data.ToolImports = append(data.ToolImports, "fmt", "reflect", "sort", "sync")
+	}
+	return renderTemplate("file", data)
+}
+
+// GenerateFiles generates the same code as GenerateMCPTools split across several
+// files, keyed by file name: doc.go holds the package comment and GeneratedAPIInfo,
+// tools.go holds the generated types and tool definitions, scopes.go (when
+// GenerateScopes is set) holds the scope constants, registry.go (when
+// GenerateRegistry is set without RegistryImportPath) defines the tool Registry,
+// tools_test.go (when GenerateTests is set) holds the round-trip tests, and
+// tools.json (when ToolsJSON is set) is the GenerateToolsJSON sidecar. With
+// SeparateSchemaFile set, schemas.go holds the schema and enum types, leaving
+// the args types and tool definitions to tools.go.
+//
+// With Subpackage set, all of these are keyed under the subpackage directory
+// (e.g. "generated/tools.go") and declare its package, and a re-export file in
+// the parent package PackageName (e.g. "generated.go") aliases every exported
+// type and forwards every exported constant, variable and function, so the
+// large generated files stay out of the hand-written package.
+func GenerateFiles(doc *Document, opts GenerateOptions) (map[string]string, error) {
+	parent := opts.PackageName
+	if opts.Subpackage != "" {
+		if parent == "" {
+			parent = "tools"
+		}
+		if err := validatePackageName(parent); err != nil {
+			return nil, err
+		}
+		if err := checkSubpackage(opts.Subpackage, opts.SubpackageImport); err != nil {
+			return nil, err
+		}
+		opts.PackageName = path.Base(opts.Subpackage)
+	}
+	data, err := newTemplateData(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := map[string]string{"doc.go": "doc", "tools.go": "tools"}
+	if opts.GenerateRegistry && opts.RegistryImportPath == "" {
+		templates["registry.go"] = "registry"
+	}
+	if opts.GenerateScopes {
+		templates["scopes.go"] = "scopesfile"
+	}
+	if opts.GenerateTests {
+		templates["tools_test.go"] = "testfile"
+	}
+	if opts.SeparateSchemaFile && (data.GenerateSchema || len(data.Enums) > 0) {
+		templates["schemas.go"] = "schemasfile"
+	}
+
+	files := make(map[string]string)
+	if opts.ToolsJSON {
+		files["tools.json"] = toolsJSON(data)
+	}
+	for name, tmpl := range templates {
+		code, err := renderTemplate(tmpl, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files[name] = code
+	}
+	if opts.Subpackage != "" {
+		return subpackageFiles(data, opts, parent, files)
+	}
+	return files, nil
+}
+
+// GenerateTestFile generates a companion _test.go file that marshals the zero value
+// of every generated args and schema struct to JSON and unmarshals it back.
+func GenerateTestFile(doc *Document, opts GenerateOptions) (string, error) {
+	data, err := newTemplateData(doc, opts)
+	if err != nil {
+		return "", err
+	}
+	return renderTemplate("testfile", data)
+}
+
+// ToolMapping describes a generated tool in the tools.json sidecar.
+type ToolMapping struct {
+	Struct     string `json:"struct"`     // Args struct name, e.g. "APIVideosListArgs"
+	HTTPMethod string `json:"httpMethod"` // e.g. "GET"
+	Path       string `json:"path"`       // Path template relative to the service path
+}
+
+// GenerateToolsJSON generates the tools.json sidecar: a JSON object mapping each
+// tool name to its ToolMapping, so dispatchers can find the args struct of a tool
+// without parsing the generated Go source.
+func GenerateToolsJSON(doc *Document, opts GenerateOptions) (string, error) {
+	data, err := newTemplateData(doc, opts)
+	if err != nil {
+		return "", err
+	}
+	return toolsJSON(data), nil
+}
+
+func toolsJSON(data *TemplateData) string {
+	tools := make(map[string]ToolMapping)
+	if !data.SchemaOnly {
+		for _, m := range data.Methods {
+			tools[m.ToolName()] = ToolMapping{Struct: m.StructName(), HTTPMethod: m.Method.HTTPMethod, Path: m.PathTemplate()}
+		}
+	}
+	out, _ := json.MarshalIndent(tools, "", "  ") // Cannot fail for strings
+	return string(out) + "\n"
+}
+
+// GenerateRegistryFile generates the source of the Registry type and DefaultRegistry
+// for the given package. Generated files built with GenerateRegistry register into it.
+// The output is independent of any API, so one file can serve several generated packages.
+func GenerateRegistryFile(packageName string) (string, error) {
+	if err := validatePackageName(packageName); err != nil {
+		return "", err
+	}
+	return renderTemplate("registry", &TemplateData{PackageName: packageName})
+}
+
+// validatePackageName checks that name can appear in a package clause.
+func validatePackageName(name string) error {
+	if !token.IsIdentifier(name) || name == "_" {
+		return fmt.Errorf("invalid package name %q: must be a Go identifier such as %q", name, "youtube")
+	}
+	return nil
+}
+
+// newTemplateData applies option defaults and resolves the methods and schemas to generate.
+func newTemplateData(doc *Document, opts GenerateOptions) (*TemplateData, error) {
 	if opts.PackageName == "" {
 		opts.PackageName = "tools"
 	}
+	if err := validatePackageName(opts.PackageName); err != nil {
+		return nil, err
+	}
+	name, err := documentName(doc)
+	if err != nil {
+		return nil, err
+	}
 	if opts.Prefix == "" {
-		opts.Prefix = doc.Name + "_"
+		opts.Prefix = name + "_"
+		if slug := titleSlug(doc.Title); opts.PrefixFromTitle && slug != "" {
+			opts.Prefix = slug + "_"
+		}
+	}
+	switch opts.JSONTagCase {
+	case "", JSONTagCaseNone, JSONTagCaseSnake, JSONTagCaseCamel:
+	default:
+		return nil, fmt.Errorf("unknown JSONTagCase %q (want %s, %s or %s)", opts.JSONTagCase, JSONTagCaseNone, JSONTagCaseSnake, JSONTagCaseCamel)
+	}
+	if len(opts.RootSchemas) > 0 {
+		opts.SchemaOnly = true
+	}
+	if opts.SchemaOnly {
+		// Methods still select the schemas, but nothing tool-related is emitted.
+		opts.GenerateSchema = true
+		opts.GenerateHandlers = false
+		opts.GenerateDispatch = false
+		opts.GenerateRegistry = false
+		opts.GenerateScopes = false
+	}
+	if opts.InputSchemaMap {
+		// Everything built on the args structs goes with them.
+		opts.GenerateHandlers = false
+		opts.GenerateDispatch = false
+		opts.GenerateRegistry = false
+		opts.GenerateAssertions = false
+		opts.GenerateURLValues = false
 	}
 	if opts.StructPrefix == "" {
 		opts.StructPrefix = "API"
 	}
+	if opts.SchemaPrefix != "" && !(token.IsIdentifier(opts.SchemaPrefix) && token.IsExported(opts.SchemaPrefix)) {
+		return nil, fmt.Errorf("invalid SchemaPrefix %q: must be an exported Go identifier", opts.SchemaPrefix)
+	}
+	goMinor, err := parseGoVersion(opts.GoVersion)
+	if err != nil {
+		return nil, err
+	}
+	if !goVersionAtLeast(goMinor, goGenerics) {
+		if opts.GenerateMarshalJSON {
+			return nil, fmt.Errorf("GenerateMarshalJSON requires GoVersion 1.%d or later", goGenerics)
+		}
+		opts.GenericListResponse = false
+	}
+	if opts.MCPImportPath == "" {
+		opts.MCPImportPath = defaultMCPImportPath
+	}
+	if opts.JSONSchemaImportPath == "" {
+		opts.JSONSchemaImportPath = defaultJSONSchemaImportPath
+	}
+	if opts.ResourceSeparator == "" {
+		opts.ResourceSeparator = "_"
+	}
 
 	allMethods := doc.AllMethods()
 	var methodsToGenerate []*MethodInfo
 
 	// Filter methods if specified
-	methodNames := opts.Methods
-	if len(methodNames) == 0 {
-		methodNames = doc.SortedMethodNames()
+	methodNames, err := filterMethods(opts, doc.SortedMethodNames())
+	if err != nil {
+		return nil, err
 	}
 
 	for _, name := range methodNames {
 		m, ok := allMethods[name]
 		if !ok {
-			return "", fmt.Errorf("method not found: %s", name)
+			return nil, fmt.Errorf("method not found: %s", name)
 		}
-		methodsToGenerate = append(methodsToGenerate, &MethodInfo{
+		info := &MethodInfo{
 			FullName:     name,
 			Method:       m,
 			Prefix:       opts.Prefix,
 			StructPrefix: opts.StructPrefix,
-		})
+		}
+		if opts.IncludeCommonParams {
+			info.CommonParams = doc.Parameters
+		}
+		info.PreserveOrder = opts.PreserveOrder
+		info.Separator = opts.ResourceSeparator
+		info.Options = &opts
+		methodsToGenerate = append(methodsToGenerate, info)
+	}
+
+	if err := validateToolNames(methodsToGenerate); err != nil {
+		return nil, err
+	}
+	if problems := methodProblems(methodsToGenerate); opts.Strict && len(problems) > 0 {
+		return nil, fmt.Errorf("malformed discovery document: %s", strings.Join(problems, "; "))
 	}
 
 	// Collect schemas needed by the methods
+	schemaIDs := schemaIDIndex(doc.Schemas)
+	for _, m := range methodsToGenerate {
+		m.SchemaIDs = schemaIDs
+	}
 	var schemasToGen []*SchemaInfo
 	if opts.GenerateSchema {
-		schemasToGen = collectSchemas(methodsToGenerate, doc.Schemas)
+		switch {
+		case len(opts.RootSchemas) > 0:
+			schemasToGen, err = collectRootSchemas(opts.RootSchemas, doc.Schemas, schemaIDs)
+			if err != nil {
+				return nil, err
+			}
+		case opts.AllSchemas:
+			schemasToGen = collectAllSchemas(doc.Schemas)
+		default:
+			schemasToGen = collectSchemas(methodsToGenerate, doc.Schemas, schemaIDs)
+		}
+		if opts.SplitReadWrite {
+			requestSchemas := collectRequestSchemas(methodsToGenerate, doc.Schemas, schemaIDs)
+			schemasToGen = append(schemasToGen, requestSchemas...)
+			for _, m := range methodsToGenerate {
+				for _, rs := range requestSchemas {
+					if m.Method.Request != nil && schemaKey(m.Method.Request.Ref, schemaIDs) == rs.Name {
+						m.SplitRequest = true
+					}
+				}
+			}
+		}
 	}
 
-	data := &TemplateData{
-		PackageName:    opts.PackageName,
-		APIName:        doc.Name,
-		APITitle:       doc.Title,
-		APIVersion:     doc.Version,
-		Methods:        methodsToGenerate,
-		Schemas:        doc.Schemas,
-		SchemasToGen:   schemasToGen,
-		AllSchemas:     doc.Schemas,
-		GenerateSchema: opts.GenerateSchema,
+	for _, s := range schemasToGen {
+		s.PreserveOrder = opts.PreserveOrder
+		s.Options = &opts
+		s.SchemaIDs = schemaIDs
 	}
 
-	var buf bytes.Buffer
-	if err := codeTemplate.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("template execution failed: %w", err)
+	buildLines, err := buildConstraintLines(opts.BuildTags)
+	if err != nil {
+		return nil, err
 	}
 
-	// Format the generated code
-	formatted, err := format.Source(buf.Bytes())
-	if err != nil {
-		// Return unformatted code with error info for debugging
-		return buf.String(), fmt.Errorf("generated code has syntax errors: %w", err)
+	var enums []*EnumInfo
+	if opts.GenerateEnums {
+		enumMethods := methodsToGenerate
+		if opts.SchemaOnly || opts.InputSchemaMap {
+			enumMethods = nil
+		}
+		registry := collectEnums(enumMethods, schemasToGen, doc.Schemas)
+		reserved := make(map[string]bool)
+		for _, m := range methodsToGenerate {
+			reserved[m.StructName()] = true
+		}
+		for name := range doc.Schemas {
+			reserved[schemaTypeName(name, &opts)] = true
+			reserved[schemaTypeName(name, &opts)+"Request"] = true
+		}
+		enums = registry.finalize(reserved)
+		for _, m := range methodsToGenerate {
+			m.Enums = registry
+		}
+		for _, s := range schemasToGen {
+			s.Enums = registry
+		}
 	}
 
-	return string(formatted), nil
-}
+	if opts.AliasDuplicates {
+		aliasDuplicateSchemas(schemasToGen)
+	}
+	if opts.GenerateFieldMask {
+		markFieldMaskSchemas(methodsToGenerate, schemasToGen)
+	}
 
-// TemplateData is passed to the code generation template.
-type TemplateData struct {
-	PackageName    string
-	APIName        string
-	APITitle       string
-	APIVersion     string
-	Methods        []*MethodInfo
-	Schemas        map[string]*Schema
-	SchemasToGen   []*SchemaInfo // Schemas to generate, in dependency order
-	AllSchemas     map[string]*Schema
-	GenerateSchema bool // Whether to generate schema types
+	data := &TemplateData{
+		PackageName:           opts.PackageName,
+		APIName:               name,
+		APITitle:              doc.Title,
+		APIVersion:            doc.Version,
+		RootURL:               doc.RootURL,
+		ServicePath:           doc.ServicePath,
+		DocsLink:              doc.DocumentationLink,
+		Revision:              doc.Revision,
+		Etag:                  doc.Etag,
+		Methods:               methodsToGenerate,
+		Schemas:               doc.Schemas,
+		SchemasToGen:          schemasToGen,
+		AllSchemas:            doc.Schemas,
+		GenerateSchema:        opts.GenerateSchema,
+		GenerateExamples:      opts.GenerateExamples,
+		GenerateInputSchema:   opts.GenerateInputSchema,
+		GenerateJSONSchema:    opts.GenerateJSONSchema,
+		SeparateSchemaFile:    opts.SeparateSchemaFile,
+		InputSchemaMap:        opts.InputSchemaMap,
+		Enums:                 enums,
+		BuildLines:            buildLines,
+		GenerateHandlers:      opts.GenerateHandlers,
+		GenerateDispatch:      opts.GenerateDispatch,
+		OmitSchemaTags:        opts.OmitSchemaTags,
+		GenerateAssertions:    opts.GenerateAssertions,
+		SchemaOnly:            opts.SchemaOnly,
+		FieldComments:         opts.FieldComments,
+		GenerateEnumValues:    opts.GenerateEnumValues,
+		GenerateDescribe:      opts.GenerateDescribe,
+		GenerateHTTPInfo:      opts.GenerateHTTPInfo,
+		OmitToolDefinitions:   opts.OmitToolDefinitions,
+		GenerateURLValues:     opts.GenerateURLValues && !opts.SchemaOnly,
+		ValidateTags:          opts.ValidateTags,
+		GenerateCheckRequired: opts.GenerateCheckRequired && !opts.SchemaOnly,
+		AnyType:               anyType(&opts),
+	}
+	if opts.GenerateScopes {
+		data.GenerateScopes = true
+		data.Scopes = collectScopes(methodsToGenerate)
+	}
+	// Imports are added by the features that refer to them, so each file's
+	// import block is exact; see ImportGroups.
+	argTypes := !opts.SchemaOnly && !opts.InputSchemaMap && len(methodsToGenerate) > 0
+	if opts.RawMessageForAny && opts.GenerateSchema && usesRawMessage(schemasToGen) {
+		data.SchemaImports = append(data.SchemaImports, "encoding/json")
+	}
+	if opts.GenerateMarshalJSON && opts.GenerateSchema && len(schemasToGen) > 0 {
+		data.GenerateMarshalJSON = true
+		data.SchemaImports = append(data.SchemaImports, "encoding/json", "reflect", "strings")
+	}
+	if opts.GenerateSchema && data.HasFieldMask() {
+		data.SchemaImports = append(data.SchemaImports, "reflect", "strings")
+	}
+	if data.GenerateURLValues && len(methodsToGenerate) > 0 {
+		data.ToolImports = append(data.ToolImports, urlValuesImports(methodsToGenerate)...)
+	}
+	if data.GenerateCheckRequired {
+		data.ToolImports = append(data.ToolImports, "fmt", "strings")
+	}
+	if opts.GenerateJSONSchema && argTypes {
+		data.ToolImports = append(data.ToolImports, jsonSchemaImport(opts.JSONSchemaImportPath))
+	}
+	if opts.GenerateDispatch {
+		data.ToolImports = append(data.ToolImports, "context", "encoding/json", "fmt")
+	}
+	if opts.GenerateHandlers {
+		data.ToolImports = append(data.ToolImports, "context", "encoding/json", mcpImport(opts.MCPImportPath))
+	}
+	if opts.GenerateRegistry {
+		data.GenerateRegistry = true
+		if opts.RegistryImportPath != "" {
+			data.RegistryQualifier = registryAlias + "."
+		}
+		if len(methodsToGenerate) > 0 {
+			data.ToolImports = append(data.ToolImports, "reflect")
+			if opts.RegistryImportPath != "" {
+				data.ToolImports = append(data.ToolImports, registryAlias+" "+opts.RegistryImportPath)
+			}
+		}
+	}
+
+	return data, nil
 }
 
-// MethodInfo wraps a Method with generation helpers.
-type MethodInfo struct {
-	FullName     string // e.g., "videos.list"
-	Method       *Method
-	Prefix       string // e.g., "youtube_"
-	StructPrefix string // e.g., "API"
+// aliasDuplicateSchemas points every schema whose fields (name, Go type and json
+// tag) match an earlier schema's at that schema through AliasOf. Schemas without
+// properties and generic list aliases are left alone.
+func aliasDuplicateSchemas(schemas []*SchemaInfo) {
+	first := make(map[string]string)
+	for _, s := range schemas {
+		if s.ListItemType() != "" {
+			continue
+		}
+		props := s.SortedProperties()
+		if len(props) == 0 {
+			continue
+		}
+		fields := make([]string, len(props))
+		for i, p := range props {
+			fields[i] = p.FieldName() + " " + p.GoType() + " " + p.JSONTag()
+		}
+		sort.Strings(fields)
+		shape := strings.Join(fields, "\n")
+		if name, ok := first[shape]; ok {
+			s.AliasOf = name
+			continue
+		}
+		first[shape] = s.StructName()
+	}
 }
 
-// ToolName returns the MCP tool name (e.g., "youtube_videos_list").
-func (m *MethodInfo) ToolName() string {
-	return m.Prefix + strings.ReplaceAll(m.FullName, ".", "_")
+// markFieldMaskSchemas sets FieldMask on the request body types of update
+// methods: PATCH methods and methods taking an updateMask parameter. When a
+// readOnly-free request variant exists, only that variant is marked.
+func markFieldMaskSchemas(methods []*MethodInfo, schemas []*SchemaInfo) {
+	bodies := make(map[string]bool)
+	for _, m := range methods {
+		if m.Method.Request == nil || m.Method.Request.Ref == "" {
+			continue
+		}
+		if _, ok := m.Method.Parameters["updateMask"]; ok || m.Method.HTTPMethod == "PATCH" {
+			bodies[schemaKey(m.Method.Request.Ref, m.SchemaIDs)] = true
+		}
+	}
+	split := make(map[string]bool)
+	for _, s := range schemas {
+		if s.Request {
+			split[s.Name] = true
+		}
+	}
+	for _, s := range schemas {
+		if bodies[s.Name] && s.Request == split[s.Name] && s.AliasOf == "" && s.ListItemType() == "" && s.SliceType() == "" {
+			s.FieldMask = true
+		}
+	}
 }
 
-// StructName returns the Go struct name for args (e.g., "APIVideosListArgs").
-func (m *MethodInfo) StructName() string {
-	parts := strings.Split(m.FullName, ".")
-	var result string
-	for _, p := range parts {
-		result += exportedName(p)
+// usesRawMessage reports whether any generated property has a json.RawMessage type.
+func usesRawMessage(schemas []*SchemaInfo) bool {
+	for _, s := range schemas {
+		for _, p := range s.SortedProperties() {
+			if strings.Contains(p.GoType(), "json.RawMessage") {
+				return true
+			}
+		}
 	}
-	return m.StructPrefix + result + "Args"
+	return false
 }
 
-// Description returns a cleaned description for the tool.
-func (m *MethodInfo) Description() string {
-	desc := cleanDescription(m.Method.Description)
-	if len(desc) > 200 {
-		desc = desc[:197] + "..."
+// filterMethods narrows all to the methods selected by opts.Methods and
+// opts.MethodsRegex. A method must satisfy both when both are set.
+func filterMethods(opts GenerateOptions, all []string) ([]string, error) {
+	names := all
+	if len(opts.Methods) > 0 {
+		var err error
+		if names, err = selectMethods(opts.Methods, names); err != nil {
+			return nil, err
+		}
 	}
-	return desc
+	if opts.MethodsRegex == "" {
+		return names, nil
+	}
+	re, err := regexp.Compile(opts.MethodsRegex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid methods regex: %w", err)
+	}
+	var matched []string
+	for _, name := range names {
+		if re.MatchString(name) {
+			matched = append(matched, name)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no methods match regex: %s", opts.MethodsRegex)
+	}
+	return matched, nil
 }
 
-// SortedParams returns parameters sorted by: required first, then alphabetically.
-func (m *MethodInfo) SortedParams() []*ParamInfo {
-	var params []*ParamInfo
-	for name, p := range m.Method.Parameters {
-		params = append(params, &ParamInfo{Name: name, Param: p})
+// selectMethods expands the requested method names and glob patterns against all
+// method names, preserving request order and dropping duplicates. Exact names that
+// don't exist and patterns that match nothing are reported as errors.
+func selectMethods(requested, all []string) ([]string, error) {
+	var selected []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			selected = append(selected, name)
+		}
 	}
-	sort.Slice(params, func(i, j int) bool {
-		// Required params first
-		if params[i].Param.Required != params[j].Param.Required {
-			return params[i].Param.Required
+
+	for _, req := range requested {
+		if !strings.ContainsAny(req, "*?[") {
+			if indexOf(all, req) == -1 {
+				return nil, fmt.Errorf("method not found: %s", req)
+			}
+			add(req)
+			continue
 		}
-		// Then by parameter order if specified
-		iOrder := indexOf(m.Method.ParameterOrder, params[i].Name)
-		jOrder := indexOf(m.Method.ParameterOrder, params[j].Name)
-		if iOrder != jOrder {
-			if iOrder == -1 {
-				return false
+		matched := false
+		for _, name := range all {
+			ok, err := path.Match(req, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid method pattern %q: %w", req, err)
 			}
-			if jOrder == -1 {
-				return true
+			if ok {
+				matched = true
+				add(name)
 			}
-			return iOrder < jOrder
 		}
-		// Then alphabetically
-		return params[i].Name < params[j].Name
-	})
-	return params
-}
-
-// ParamInfo wraps a Parameter with generation helpers.
-type ParamInfo struct {
-	Name  string
-	Param *Parameter
+		if !matched {
+			return nil, fmt.Errorf("no methods match pattern: %s", req)
+		}
+	}
+	return selected, nil
 }
 
-// FieldName returns the Go field name (exported).
-func (p *ParamInfo) FieldName() string {
-	return exportedName(p.Name)
+// buildConstraintLines combines tags into a single constraint and renders it in both
+// the //go:build and legacy // +build forms.
+func buildConstraintLines(tags []string) ([]string, error) {
+	var expr constraint.Expr
+	for _, tag := range tags {
+		x, err := constraint.Parse("//go:build " + tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid build tag %q: %w", tag, err)
+		}
+		if expr == nil {
+			expr = x
+		} else {
+			expr = &constraint.AndExpr{X: expr, Y: x}
+		}
+	}
+	if expr == nil {
+		return nil, nil
+	}
+	plusLines, err := constraint.PlusBuildLines(expr)
+	if err != nil {
+		return nil, fmt.Errorf("build tags %v: %w", tags, err)
+	}
+	return append([]string{"//go:build " + expr.String()}, plusLines...), nil
 }
 
-// JSONTag returns the json struct tag.
-func (p *ParamInfo) JSONTag() string {
-	if p.Param.Required {
-		return p.Name
+// HasGenericList reports whether any schema is generated as a ListResponse[T] alias.
+func (d *TemplateData) HasGenericList() bool {
+	for _, s := range d.SchemasToGen {
+		if s.ListItemType() != "" {
+			return true
+		}
 	}
-	return p.Name + ",omitempty"
+	return false
 }
 
-// GoType returns the Go type for this parameter.
-func (p *ParamInfo) GoType() string {
-	return paramGoType(p.Param)
+// EnumField lists the allowed values of one enum field.
+type EnumField struct {
+	Key    string   // "StructName.FieldName"
+	Values []string // Allowed values, in document order
 }
 
-// SchemaDescription returns the jsonschema description.
-func (p *ParamInfo) SchemaDescription() string {
-	desc := cleanDescription(p.Param.Description)
+// EnumFields returns every args and schema struct field restricted to an enum,
+// sorted by key.
+func (d *TemplateData) EnumFields() []EnumField {
+	var fields []EnumField
+	if !d.SchemaOnly {
+		for _, m := range d.Methods {
+			for _, p := range m.SortedParams() {
+				if len(p.Param.Enum) > 0 {
+					fields = append(fields, EnumField{Key: m.StructName() + "." + p.FieldName(), Values: p.Param.Enum})
+				}
+			}
+		}
+	}
+	for _, s := range d.SchemasToGen {
+		if s.AliasOf != "" || s.ListItemType() != "" {
+			continue
+		}
+		for _, p := range s.SortedProperties() {
+			if values := p.enumValues(); len(values) > 0 {
+				fields = append(fields, EnumField{Key: s.StructName() + "." + p.FieldName(), Values: values})
+			}
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
 
-	// Add enum values to description if present
-	if len(p.Param.Enum) > 0 {
-		enumStr := strings.Join(p.Param.Enum, ", ")
-		if desc != "" {
-			desc += " "
+// HasFieldMask reports whether any schema type gets a FieldMask method.
+func (d *TemplateData) HasFieldMask() bool {
+	for _, s := range d.SchemasToGen {
+		if s.FieldMask {
+			return true
 		}
-		desc += "Values: " + enumStr
 	}
+	return false
+}
 
-	// Add default if present
-	if p.Param.Default != "" {
-		desc += " (default: " + p.Param.Default + ")"
+// ToolNames returns the tool names of the methods, sorted.
+func (d *TemplateData) ToolNames() []string {
+	names := make([]string, len(d.Methods))
+	for i, m := range d.Methods {
+		names[i] = m.ToolName()
 	}
+	sort.Strings(names)
+	return names
+}
 
-	return desc
+// HasRepeatedPathParams reports whether any method has a repeated path parameter.
+func (d *TemplateData) HasRepeatedPathParams() bool {
+	for _, m := range d.Methods {
+		if len(m.RepeatedPathParams()) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
-// SchemaInfo wraps a Schema with generation helpers.
-type SchemaInfo struct {
-	Name        string             // Schema name (e.g., "Video", "VideoStatus")
-	Schema      *Schema            // The schema definition
-	AllSchemas  map[string]*Schema // Reference to all schemas for resolving $ref
-	RequiredSet map[string]bool    // Set of required property names
+// HasResponseMetadata reports whether any method has response metadata to emit.
+func (d *TemplateData) HasResponseMetadata() bool {
+	for _, m := range d.Methods {
+		if m.HasResponseMetadata() {
+			return true
+		}
+	}
+	return false
 }
 
-// NewSchemaInfo creates a SchemaInfo from a schema.
-func NewSchemaInfo(name string, schema *Schema, allSchemas map[string]*Schema) *SchemaInfo {
-	requiredSet := make(map[string]bool)
-	if schema.Annotations != nil {
-		for _, req := range schema.Annotations.Required {
-			requiredSet[req] = true
+// ImportGroups renders the deduplicated imports of a file as import specs split
+// into standard library and third-party groups, each sorted by path, omitting
+// empty groups. schemas and tools say whether the file holds the schema and
+// enum types and the args types and tool code, respectively.
+func (d *TemplateData) ImportGroups(schemas, tools bool) [][]string {
+	var imports []string
+	if schemas {
+		imports = append(imports, d.SchemaImports...)
+	}
+	if tools {
+		imports = append(imports, d.ToolImports...)
+	}
+	var std, other []string
+	seen := make(map[string]bool)
+	for _, imp := range imports {
+		if seen[imp] {
+			continue
+		}
+		seen[imp] = true
+		alias, importPath, ok := strings.Cut(imp, " ")
+		if !ok {
+			alias, importPath = "", imp
+		}
+		spec := strconv.Quote(importPath)
+		if alias != "" {
+			spec = alias + " " + spec
+		}
+		first, _, _ := strings.Cut(importPath, "/")
+		if strings.Contains(first, ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
 		}
 	}
-	return &SchemaInfo{
-		Name:        name,
-		Schema:      schema,
-		AllSchemas:  allSchemas,
-		RequiredSet: requiredSet,
+	byPath := func(specs []string) func(i, j int) bool {
+		return func(i, j int) bool {
+			return specs[i][strings.Index(specs[i], `"`):] < specs[j][strings.Index(specs[j], `"`):]
+		}
+	}
+	sort.Slice(std, byPath(std))
+	sort.Slice(other, byPath(other))
+	var groups [][]string
+	for _, g := range [][]string{std, other} {
+		if len(g) > 0 {
+			groups = append(groups, g)
+		}
 	}
+	return groups
 }
 
-// StructName returns the Go struct name for this schema.
-func (s *SchemaInfo) StructName() string {
-	return exportedName(s.Name)
+// renderTemplate executes the named template and formats the result.
+func renderTemplate(name string, data any) (string, error) {
+	formatted, err := executeTemplate(name, data)
+	if err != nil {
+		return formatted, err
+	}
+	return string(normalizeSource([]byte(formatted))), nil
 }
 
-// Description returns the schema description.
-func (s *SchemaInfo) Description() string {
-	return cleanDescription(s.Schema.Description)
-}
+// executeTemplate executes the named template and formats the result with gofmt.
+func executeTemplate(name string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := codeTemplate.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("template execution failed: %w", err)
+	}
 
-// SortedProperties returns schema properties sorted by: required first, then alphabetically.
-func (s *SchemaInfo) SortedProperties() []*PropertyInfo {
-	var props []*PropertyInfo
-	for name, prop := range s.Schema.Properties {
-		required := s.RequiredSet[name] || prop.Required
-		props = append(props, &PropertyInfo{
-			Name:       name,
-			Property:   prop,
-			Required:   required,
-			AllSchemas: s.AllSchemas,
-		})
+	// Format the generated code
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		// Return unformatted code with error info for debugging
+		return buf.String(), fmt.Errorf("generated code has syntax errors: %w", err)
 	}
-	sort.Slice(props, func(i, j int) bool {
-		if props[i].Required != props[j].Required {
-			return props[i].Required
-		}
-		return props[i].Name < props[j].Name
-	})
-	return props
+	return string(formatted), nil
 }
 
-// PropertyInfo wraps a schema property with generation helpers.
-type PropertyInfo struct {
-	Name       string
-	Property   *Schema
-	Required   bool
-	AllSchemas map[string]*Schema
+// normalizeSource pins down the layout details gofmt leaves open or has changed
+// between Go releases, so identical input yields byte-identical output whichever
+// toolchain builds the generator: "\n" line endings, no trailing whitespace, at
+// most one consecutive blank line, no blank lines right after an opening or
+// before a closing brace, and exactly one trailing newline. Import grouping is
+// already fixed by TemplateData.ImportGroups. Generated descriptions never span
+// lines, so no multi-line literal is affected.
+func normalizeSource(src []byte) []byte {
+	src = bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+	lines := strings.Split(string(src), "\n")
+	out := make([]string, 0, len(lines))
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if len(out) == 0 || out[len(out)-1] == "" || strings.HasSuffix(out[len(out)-1], "{") {
+				continue
+			}
+			if next := nextNonBlank(lines, i); strings.HasPrefix(next, "}") || next == "" {
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
 }
 
-// FieldName returns the Go field name (exported).
-func (p *PropertyInfo) FieldName() string {
-	return exportedName(p.Name)
+// nextNonBlank returns the first line after lines[i] with non-whitespace content,
+// trimmed of leading whitespace, or "" if there is none.
+func nextNonBlank(lines []string, i int) string {
+	for _, line := range lines[i+1:] {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
 }
 
-// JSONTag returns the json struct tag.
-func (p *PropertyInfo) JSONTag() string {
-	if p.Required {
-		return p.Name
-	}
-	return p.Name + ",omitempty"
+// TemplateData is passed to the code generation template.
+type TemplateData struct {
+	PackageName           string
+	APIName               string
+	APITitle              string
+	APIVersion            string
+	RootURL               string
+	ServicePath           string
+	DocsLink              string
+	Revision              string
+	Etag                  string
+	Methods               []*MethodInfo
+	Schemas               map[string]*Schema
+	SchemasToGen          []*SchemaInfo // Schemas to generate, in dependency order
+	AllSchemas            map[string]*Schema
+	GenerateSchema        bool         // Whether to generate schema types
+	GenerateExamples      bool         // Whether to emit example comments above args structs
+	GenerateInputSchema   bool         // Whether to generate InputSchema() methods
+	GenerateJSONSchema    bool         // Whether to generate reflection-free JSONSchema() methods
+	SeparateSchemaFile    bool         // Whether tools.go leaves the schema and enum types to schemas.go
+	InputSchemaMap        bool         // Whether GeneratedInputSchemas replaces the args structs
+	Enums                 []*EnumInfo  // Shared enum types, sorted by type name
+	BuildLines            []string     // "//go:build" and "// +build" lines, empty if no tags
+	GenerateHandlers      bool         // Whether to generate handler stubs
+	GenerateDispatch      bool         // Whether to generate ToolFuncs and its Dispatch method
+	SchemaImports         []string     // Import paths the schema and enum types refer to, optionally "alias path"
+	ToolImports           []string     // Import paths the args types and tool code refer to, optionally "alias path"
+	OmitSchemaTags        bool         // Whether to omit jsonschema struct tags
+	GenerateRegistry      bool         // Whether to generate the registry init()
+	RegistryQualifier     string       // Package qualifier for DefaultRegistry (e.g. "toolregistry."), empty if local
+	RegistryTypes         bool         // Whether the file itself defines Registry and DefaultRegistry
+	GenerateScopes        bool         // Whether to generate scope constants, the scope map and Scopes methods
+	Scopes                []*ScopeInfo // OAuth scope constants to generate (nil unless GenerateScopes)
+	GenerateMarshalJSON   bool         // Whether schema types get a MarshalJSON method
+	GenerateAssertions    bool         // Whether to emit the args type compile-time check
+	SchemaOnly            bool         // Whether to skip everything tool-related
+	FieldComments         bool         // Whether fields get description comments
+	GenerateEnumValues    bool         // Whether to emit the GeneratedEnumValues map
+	GenerateDescribe      bool         // Whether args structs get a Describe method
+	GenerateHTTPInfo      bool         // Whether args structs get HTTPMethod, PathTemplate and FlatPathTemplate methods
+	OmitToolDefinitions   bool         // Whether to skip the GeneratedToolDefinitions map
+	GenerateURLValues     bool         // Whether args structs get a ToURLValues method
+	ValidateTags          bool         // Whether fields get validate tags
+	GenerateCheckRequired bool         // Whether GeneratedRequiredParams and CheckRequired are generated
+	AnyType               string       // Go type of values of unknown type, see anyType
 }
 
-// GoType returns the Go type for this property.
-func (p *PropertyInfo) GoType() string {
-	return p.resolveType(p.Property, !p.Required)
+// MethodInfo wraps a Method with generation helpers.
+type MethodInfo struct {
+	FullName      string // e.g., "videos.list"
+	Method        *Method
+	Prefix        string                // e.g., "youtube_"
+	StructPrefix  string                // e.g., "API"
+	CommonParams  map[string]*Parameter // Document-level parameters merged into Parameters
+	Enums         *enumRegistry         // Shared enum types (nil when not generating enums)
+	PreserveOrder bool                  // Keep parameters in document order
+	Separator     string                // Separator between resource levels in ToolName (default: "_")
+	Options       *GenerateOptions      // Generation options (nil means defaults)
+	SplitRequest  bool                  // The request body has a readOnly-free "<Name>Request" variant
+	SchemaIDs     map[string]string     // Schema ID -> document key, for $refs naming an ID (see schemaIDIndex)
 }
 
-// resolveType resolves the Go type for a schema, handling refs, arrays, objects, etc.
-func (p *PropertyInfo) resolveType(schema *Schema, optional bool) string {
-	// Handle $ref
-	if schema.Ref != "" {
-		// Reference to another schema - use its exported name
-		refType := exportedName(schema.Ref)
-		// Check if the referenced schema is a simple type (wrapper)
-		if refSchema, ok := p.AllSchemas[schema.Ref]; ok {
-			if refSchema.Type != "" && refSchema.Type != "object" && refSchema.Type != "array" {
-				return scalarGoType(refSchema.Type, refSchema.Format, optional)
-			}
-		}
-		return "*" + refType
+// ToolName returns the MCP tool name (e.g., "youtube_videos_list").
+// Characters outside [a-zA-Z0-9_-] are replaced with "_" so names stay MCP-compliant.
+func (m *MethodInfo) ToolName() string {
+	sep := m.Separator
+	if sep == "" {
+		sep = "_"
 	}
+	return m.Prefix + sanitizeToolName(strings.ReplaceAll(m.FullName, ".", sep))
+}
 
-	switch schema.Type {
-	case "array":
-		if schema.Items != nil {
-			elemType := p.resolveType(schema.Items, false) // array elements aren't individually optional
-			return "[]" + elemType
+// titleSlug turns an API title into a lowercase tool name fragment, e.g.
+// "YouTube Data API v3" becomes "youtube_data_api_v3".
+func titleSlug(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+			b.WriteByte('_')
 		}
-		return "[]any"
-	case "object":
-		if schema.AdditionalProperties != nil {
-			valueType := p.resolveType(schema.AdditionalProperties, false)
-			return "map[string]" + valueType
-		}
-		// Inline object - use any since we can't generate anonymous structs well
-		return "map[string]any"
-	default:
-		return scalarGoType(schema.Type, schema.Format, optional)
 	}
+	return strings.TrimSuffix(b.String(), "_")
 }
 
-// SchemaDescription returns the jsonschema description for this property.
-func (p *PropertyInfo) SchemaDescription() string {
-	desc := cleanDescription(p.Property.Description)
+// documentName returns the API name used for the default tool prefix. Partial
+// documents without a name fall back to the name part of the ID ("youtube:v3")
+// and then to the slugified title.
+func documentName(doc *Document) (string, error) {
+	if doc.Name != "" {
+		return doc.Name, nil
+	}
+	id, _, _ := strings.Cut(doc.ID, ":")
+	if slug := titleSlug(id); slug != "" {
+		return slug, nil
+	}
+	if slug := titleSlug(doc.Title); slug != "" {
+		return slug, nil
+	}
+	return "", errors.New("discovery document has no name, id or title to derive the API name from")
+}
 
-	// Add enum values to description if present
-	if len(p.Property.Enum) > 0 {
-		enumStr := strings.Join(p.Property.Enum, ", ")
-		if desc != "" {
-			desc += " "
+// validateToolNames checks that every tool name is non-empty, uses only the
+// characters MCP allows ([a-zA-Z0-9_-]) and is unique across the methods.
+func validateToolNames(methods []*MethodInfo) error {
+	toolNames := make(map[string]string)
+	for _, m := range methods {
+		name := m.ToolName()
+		if name == "" {
+			return fmt.Errorf("method %s has an empty tool name", m.FullName)
 		}
-		desc += "Values: " + enumStr
+		if sanitized := sanitizeToolName(name); sanitized != name {
+			return fmt.Errorf("tool name %q for method %s contains characters outside [a-zA-Z0-9_-]", name, m.FullName)
+		}
+		if other, ok := toolNames[name]; ok {
+			return fmt.Errorf("methods %s and %s both map to tool name %q", other, m.FullName, name)
+		}
+		toolNames[name] = m.FullName
 	}
+	return nil
+}
 
-	// Add default if present
-	if p.Property.Default != "" {
-		desc += " (default: " + p.Property.Default + ")"
+// DocumentProblems reports the inconsistencies in the methods GenerateMCPTools
+// would generate for the same options that it otherwise tolerates, such as a
+// parameterOrder naming a parameter the method does not have. With opts.Strict
+// these fail generation instead.
+func DocumentProblems(doc *Document, opts GenerateOptions) ([]string, error) {
+	opts.Strict = false
+	data, err := newTemplateData(doc, opts)
+	if err != nil {
+		return nil, err
 	}
+	return methodProblems(data.Methods), nil
+}
 
-	// Add read-only indicator
-	if p.Property.ReadOnly {
-		desc += " (read-only)"
+// InputlessMethods returns the names of the methods GenerateMCPTools would
+// generate empty args structs for, because they take no parameters and no
+// request body. Such tools are valid but often point at a misconfigured method
+// selection.
+func InputlessMethods(doc *Document, opts GenerateOptions) ([]string, error) {
+	data, err := newTemplateData(doc, opts)
+	if err != nil {
+		return nil, err
 	}
-
-	return desc
+	if data.SchemaOnly || data.InputSchemaMap {
+		return nil, nil
+	}
+	var names []string
+	for _, m := range data.Methods {
+		if m.TakesNoInput() {
+			names = append(names, m.FullName)
+		}
+	}
+	return names, nil
 }
 
-// cleanDescription sanitizes a description for use in Go struct tags.
-func cleanDescription(desc string) string {
-	desc = strings.ReplaceAll(desc, "\n", " ")
-	desc = strings.ReplaceAll(desc, `"`, "'") // Replace double quotes
-	desc = strings.ReplaceAll(desc, "`", "'") // Replace backticks
-	desc = strings.TrimSpace(desc)
-	// Collapse multiple spaces
-	for strings.Contains(desc, "  ") {
-		desc = strings.ReplaceAll(desc, "  ", " ")
+// methodProblems describes the parameterOrder entries of each method that name
+// no parameter. SortedParams skips them, so they only hint at a malformed document.
+func methodProblems(methods []*MethodInfo) []string {
+	var problems []string
+	for _, m := range methods {
+		for _, name := range m.Method.ParameterOrder {
+			if _, ok := m.Method.Parameters[name]; !ok {
+				problems = append(problems, fmt.Sprintf("method %s: parameterOrder lists unknown parameter %q", m.FullName, name))
+			}
+		}
 	}
-	return desc
+	return problems
 }
 
-// Helper functions
+// sanitizeToolName replaces characters not allowed in MCP tool names with "_".
+func sanitizeToolName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
 
-func exportedName(s string) string {
-	if s == "" {
-		return ""
+// StructName returns the Go struct name for args (e.g., "APIVideosListArgs").
+func (m *MethodInfo) StructName() string {
+	parts := strings.Split(m.FullName, ".")
+	var result string
+	for _, p := range parts {
+		result += exportedName(p)
 	}
-	// Handle camelCase and snake_case
-	s = strings.ReplaceAll(s, "_", " ")
-	s = strings.ReplaceAll(s, "-", " ")
+	return m.StructPrefix + result + "Args"
+}
 
-	words := strings.Fields(s)
-	for i, w := range words {
-		if w != "" {
-			words[i] = strings.ToUpper(w[:1]) + w[1:]
-		}
-	}
-	result := strings.Join(words, "")
+// TakesNoInput reports whether the method has no parameters, request body or
+// media upload, so its tool takes no input and its args struct is empty.
+func (m *MethodInfo) TakesNoInput() bool {
+	return len(m.SortedParams()) == 0 && m.RequestType() == "" && m.Method.MediaUpload == nil
+}
 
-	// Handle acronyms
-	result = strings.ReplaceAll(result, "Id", "ID")
-	result = strings.ReplaceAll(result, "Url", "URL")
-	result = strings.ReplaceAll(result, "Http", "HTTP")
-	result = strings.ReplaceAll(result, "Api", "API")
+// HandlerName returns the generated handler function name (e.g., "handleVideosList").
+func (m *MethodInfo) HandlerName() string {
+	return "handle" + m.FuncName()
+}
 
-	// Ensure first char is uppercase
-	if result != "" {
-		runes := []rune(result)
-		runes[0] = unicode.ToUpper(runes[0])
-		result = string(runes)
+// FuncName returns the method's name as an exported identifier (e.g.,
+// "VideosList"), naming its ToolFuncs field.
+func (m *MethodInfo) FuncName() string {
+	var result string
+	for _, p := range strings.Split(m.FullName, ".") {
+		result += exportedName(p)
 	}
-
 	return result
 }
 
-func paramGoType(p *Parameter) string {
-	optional := !p.Required
-	if p.Repeated {
-		return "[]" + scalarGoType(p.Type, p.Format, false) // array elements aren't optional
-	}
-	return scalarGoType(p.Type, p.Format, optional)
+// PathTemplate returns the method's path as an RFC 6570 template whose
+// placeholders name its parameters. "{+parent}" marks reserved expansion: the
+// value is inserted with its slashes intact.
+func (m *MethodInfo) PathTemplate() string {
+	return m.Method.Path
 }
 
-// scalarGoType returns the Go type for a scalar Discovery Document type.
-// If optional is true and it's a boolean, returns *bool to distinguish absent from false.
-func scalarGoType(typ, typeFormat string, optional bool) string {
-	switch typ {
-	case "string":
-		return "string"
-	case "integer":
-		switch typeFormat {
-		case "int32":
-			return "int32"
-		case "uint32":
-			return "uint32"
-		case "int64":
-			return "int64"
-		case "uint64":
-			return "uint64"
-		default:
-			return "int64"
-		}
-	case "number":
-		switch typeFormat {
-		case "float":
-			return "float32"
-		case "double":
-			return "float64"
-		default:
-			return "float64"
-		}
-	case "boolean":
-		if optional {
-			return "*bool"
-		}
-		return "bool"
-	case "any":
-		return "any"
-	default:
-		return "any"
-	}
+// FlatPathTemplate returns the method's flatPath, which spells out every path
+// segment (e.g. "v1/projects/{projectsId}/topics"), or "" when the document
+// has none. Its placeholders name path segments, not parameters.
+func (m *MethodInfo) FlatPathTemplate() string {
+	return m.Method.FlatPath
 }
 
-func indexOf(slice []string, s string) int {
-	for i, v := range slice {
-		if v == s {
-			return i
-		}
+// ResponseType returns the Go type name of the method's alt=json response body,
+// or "" if the method has no response schema.
+func (m *MethodInfo) ResponseType() string {
+	if m.Method.Response == nil || m.Method.Response.Ref == "" {
+		return ""
 	}
-	return -1
+	return schemaTypeName(schemaKey(m.Method.Response.Ref, m.SchemaIDs), m.Options)
 }
 
-// collectSchemas collects all schemas needed by the given methods, including dependencies.
-// Returns schemas in dependency order (dependencies first).
-func collectSchemas(methods []*MethodInfo, allSchemas map[string]*Schema) []*SchemaInfo {
-	needed := make(map[string]bool)
-
-	// Find all directly referenced schemas
-	for _, m := range methods {
-		if m.Method.Request != nil && m.Method.Request.Ref != "" {
-			collectSchemaRefs(m.Method.Request.Ref, allSchemas, needed)
-		}
-		if m.Method.Response != nil && m.Method.Response.Ref != "" {
-			collectSchemaRefs(m.Method.Response.Ref, allSchemas, needed)
-		}
+// RequestType returns the Go type name of the method's request body, or "" if
+// the method takes none. It is the request variant when the schema is split.
+func (m *MethodInfo) RequestType() string {
+	if m.Method.Request == nil || m.Method.Request.Ref == "" {
+		return ""
 	}
+	name := schemaTypeName(schemaKey(m.Method.Request.Ref, m.SchemaIDs), m.Options)
+	if m.SplitRequest {
+		return name + "Request"
+	}
+	return name
+}
 
-	// Convert to SchemaInfo list, sorted by name for deterministic output
+// HasResponseMetadata reports whether the method has a request or JSON response
+// type or supports alt=media downloads, giving it a GeneratedToolResponses entry.
+func (m *MethodInfo) HasResponseMetadata() bool {
+	return m.RequestType() != "" || m.ResponseType() != "" || m.Method.SupportsMediaDownload
+}
+
+// RepeatedPathParams returns the names of the method's repeated path parameters, sorted.
+func (m *MethodInfo) RepeatedPathParams() []string {
 	var names []string
-	for name := range needed {
-		names = append(names, name)
+	for _, p := range m.SortedParams() {
+		if p.RepeatedPath() {
+			names = append(names, p.Name)
+		}
 	}
 	sort.Strings(names)
+	return names
+}
 
-	var result []*SchemaInfo
-	for _, name := range names {
-		if schema, ok := allSchemas[name]; ok {
-			result = append(result, NewSchemaInfo(name, schema, allSchemas))
+// RequiredArgs returns the argument keys of the method's required parameters,
+// in args struct order.
+func (m *MethodInfo) RequiredArgs() []string {
+	var names []string
+	for _, p := range m.SortedParams() {
+		if p.Required() {
+			names = append(names, p.WireName())
 		}
 	}
-
-	return result
+	return names
 }
 
-// collectSchemaRefs recursively collects a schema and all its dependencies.
-func collectSchemaRefs(schemaName string, allSchemas map[string]*Schema, needed map[string]bool) {
-	if needed[schemaName] {
-		return // Already collected
-	}
-
-	schema, ok := allSchemas[schemaName]
-	if !ok {
-		return // Schema not found
+// Description returns a cleaned description for the tool.
+func (m *MethodInfo) Description() string {
+	desc := cleanDescription(m.Method.Description)
+	if len(desc) > 200 {
+		desc = desc[:197] + "..."
 	}
+	return desc
+}
 
-	needed[schemaName] = true
-
-	// Collect property references
-	for _, prop := range schema.Properties {
-		collectSchemaRefsFromSchema(prop, allSchemas, needed)
+// ToolDescription returns the tool description registered with MCP hosts:
+// Description, with Markdown syntax escaped when EscapeMarkdown is set, since
+// hosts often render it as Markdown.
+func (m *MethodInfo) ToolDescription() string {
+	if m.Options != nil && m.Options.EscapeMarkdown {
+		return escapeMarkdown(m.Description())
 	}
+	return m.Description()
+}
 
-	// Collect items references (for arrays)
-	if schema.Items != nil {
-		collectSchemaRefsFromSchema(schema.Items, allSchemas, needed)
+// DescribeText returns the description Describe() reports: ToolDescription, or
+// the full cleaned description, escaped the same way, when DescribeFull is set.
+func (m *MethodInfo) DescribeText() string {
+	if m.Options == nil || !m.Options.DescribeFull {
+		return m.ToolDescription()
 	}
-
-	// Collect additionalProperties references (for maps)
-	if schema.AdditionalProperties != nil {
-		collectSchemaRefsFromSchema(schema.AdditionalProperties, allSchemas, needed)
+	desc := cleanDescription(m.Method.Description)
+	if m.Options.EscapeMarkdown {
+		return escapeMarkdown(desc)
 	}
+	return desc
 }
 
-// collectSchemaRefsFromSchema collects schema references from a schema definition.
-func collectSchemaRefsFromSchema(schema *Schema, allSchemas map[string]*Schema, needed map[string]bool) {
-	if schema.Ref != "" {
-		collectSchemaRefs(schema.Ref, allSchemas, needed)
-	}
-	for _, prop := range schema.Properties {
-		collectSchemaRefsFromSchema(prop, allSchemas, needed)
+// SortedParams returns the method's parameters, plus any common parameters it
+// doesn't override. They are ordered required first, then as listed in
+// parameterOrder, then path parameters by their position in the path, then by
+// name; with PreserveOrder they keep document order instead.
+func (m *MethodInfo) SortedParams() []*ParamInfo {
+	var params []*ParamInfo
+	for name, p := range m.CommonParams {
+		if _, ok := m.Method.Parameters[name]; ok {
+			continue
+		}
+		params = append(params, &ParamInfo{Name: name, Param: p, MethodID: m.Method.ID, Enums: m.Enums, Options: m.Options})
 	}
-	if schema.Items != nil {
-		collectSchemaRefsFromSchema(schema.Items, allSchemas, needed)
+	for name, p := range m.Method.Parameters {
+		params = append(params, &ParamInfo{Name: name, Param: p, MethodID: m.Method.ID, Enums: m.Enums, Options: m.Options})
 	}
-	if schema.AdditionalProperties != nil {
-		collectSchemaRefsFromSchema(schema.AdditionalProperties, allSchemas, needed)
+	if m.PreserveOrder {
+		sort.Slice(params, func(i, j int) bool {
+			return declaredLess(m.Method.ParameterKeys, params[i].Name, params[j].Name)
+		})
+		return params
 	}
-}
-
-var codeTemplate = template.Must(template.New("mcp").Parse(`// Code generated by google-discovery-mcp. DO NOT EDIT.
-// Source: {{.APIName}} {{.APIVersion}}
-// API: {{.APITitle}}
-
-package {{.PackageName}}
-{{if .GenerateSchema}}
-// =============================================================================
-// Schema Types (Request/Response Bodies)
-// =============================================================================
-{{range .SchemasToGen}}
-// {{.StructName}} - {{.Description}}
-type {{.StructName}} struct {
-{{- range .SortedProperties}}
-	{{.FieldName}} {{.GoType}} ` + "`" + `json:"{{.JSONTag}}" jsonschema:"{{.SchemaDescription}}"` + "`" + `
-{{- end}}
-}
-{{end}}{{end}}
+	sort.Slice(params, func(i, j int) bool {
+		// Required params first
+		if params[i].Required() != params[j].Required() {
+			return params[i].Required()
+		}
+		// Then by parameter order if specified
+		iOrder := indexOf(m.Method.ParameterOrder, params[i].Name)
+		jOrder := indexOf(m.Method.ParameterOrder, params[j].Name)
+		if iOrder != jOrder {
+			if iOrder == -1 {
+				return false
+			}
+			if jOrder == -1 {
+				return true
+			}
+			return iOrder < jOrder
+		}
+		// Then path params not listed in parameterOrder, ancestors first as they
+		// appear in the path, ahead of query params
+		iPath := pathPosition(m.Method.Path, params[i])
+		jPath := pathPosition(m.Method.Path, params[j])
+		if iPath != jPath {
+			if iPath == -1 {
+				return false
+			}
+			if jPath == -1 {
+				return true
+			}
+			return iPath < jPath
+		}
+		// Then alphabetically
+		return params[i].Name < params[j].Name
+	})
+	return params
+}
+
+// pathPosition returns the offset of a path parameter's "{name}" or "{+name}"
+// placeholder in path, or -1 for other parameters.
+func pathPosition(path string, p *ParamInfo) int {
+	if p.Param.Location != "path" {
+		return -1
+	}
+	if i := strings.Index(path, "{"+p.Name+"}"); i != -1 {
+		return i
+	}
+	return strings.Index(path, "{+"+p.Name+"}")
+}
+
+// Example returns a doc comment block showing an args literal with all required
+// parameters populated with placeholder values.
+func (m *MethodInfo) Example() string {
+	var b strings.Builder
+	b.WriteString("//\n// Example:\n//\n//\t" + m.StructName() + "{\n")
+	for _, p := range m.SortedParams() {
+		if !p.Required() {
+			continue
+		}
+		b.WriteString("//\t\t" + p.FieldName() + ": " + p.ExampleValue() + ",\n")
+	}
+	b.WriteString("//\t}")
+	return b.String()
+}
+
+// ParamInfo wraps a Parameter with generation helpers.
+type ParamInfo struct {
+	Name     string
+	Param    *Parameter
+	MethodID string // ID of the method the parameter belongs to (e.g. "youtube.videos.insert")
+	Enums    *enumRegistry
+	Options  *GenerateOptions // Generation options (nil means defaults)
+}
+
+// Required reports whether the parameter is required for its method, see
+// Parameter.RequiredFor.
+func (p *ParamInfo) Required() bool {
+	return p.Param.RequiredFor(p.MethodID)
+}
+
+// RepeatedPath reports whether the parameter is a repeated path parameter. Its
+// values are stored as a slice but share a single path segment.
+func (p *ParamInfo) RepeatedPath() bool {
+	return p.Param.Location == "path" && p.Param.Repeated
+}
+
+// FieldName returns the Go field name (exported).
+func (p *ParamInfo) FieldName() string {
+	return exportedName(p.Name)
+}
+
+// JSONTag returns the json struct tag.
+func (p *ParamInfo) JSONTag() string {
+	if p.Required() {
+		return p.WireName()
+	}
+	return p.WireName() + ",omitempty"
+}
+
+// CommentLines returns the parameter description wrapped for a field comment.
+func (p *ParamInfo) CommentLines() []string {
+	return wrapComment(cleanDescription(p.Param.Description), commentWidth)
+}
+
+// WireName returns the JSON field name, after applying JSONTagCase.
+func (p *ParamInfo) WireName() string {
+	return applyJSONTagCase(p.Name, p.Options)
+}
+
+// GoType returns the Go type for this parameter.
+func (p *ParamInfo) GoType() string {
+	if t := p.Enums.typeFor(p.Param.Type, p.Param.Enum); t != "" {
+		if p.Param.Repeated {
+			return "[]" + t
+		}
+		return optionalScalar(t, !p.Required(), p.Options)
+	}
+	if p.Param.Repeated {
+		return paramGoType(p.Param, p.Required(), p.Options)
+	}
+	return optionalScalar(paramGoType(p.Param, p.Required(), p.Options), !p.Required(), p.Options)
+}
+
+// ExampleValue returns a Go literal placeholder for this parameter, preferring
+// the first enum value, then the default, then a type-appropriate stand-in.
+func (p *ParamInfo) ExampleValue() string {
+	v := exampleScalar(p.Name, p.Param)
+	if p.Param.Repeated {
+		return p.GoType() + "{" + v + "}"
+	}
+	return v
+}
+
+func exampleScalar(name string, param *Parameter) string {
+	value := param.Default
+	if len(param.Enum) > 0 {
+		value = param.Enum[0]
+	}
+	switch param.Type {
+	case "string":
+		if value == "" {
+			value = "<" + name + ">"
+		}
+		return strconv.Quote(value)
+	case "integer", "number":
+		if value == "" {
+			value = param.Minimum
+		}
+		if value == "" {
+			value = "0"
+		}
+		return value
+	case "boolean":
+		if b, ok := parseBoolDefault(value); ok {
+			return strconv.FormatBool(b)
+		}
+		return "true"
+	default:
+		return "nil"
+	}
+}
+
+// parseBoolDefault parses the string default of a boolean parameter or property,
+// such as "true" or "False". ok is false if it is not a boolean.
+func parseBoolDefault(def string) (value, ok bool) {
+	b, err := strconv.ParseBool(strings.TrimSpace(def))
+	return b, err == nil
+}
+
+// defaultText returns a default as shown in descriptions: the canonical "true"
+// or "false" for booleans, the cleaned default otherwise.
+func defaultText(typ, def string) string {
+	if b, ok := parseBoolDefault(def); typ == "boolean" && ok {
+		return strconv.FormatBool(b)
+	}
+	return cleanDescription(def)
+}
+
+// SchemaDescription returns the jsonschema description, escaped for the struct tag.
+func (p *ParamInfo) SchemaDescription() string {
+	desc := truncateWords(cleanDescription(p.Param.Description), p.Options.maxFieldDescLen())
+
+	// Add enum values to description if present
+	if len(p.Param.Enum) > 0 {
+		enumStr := cleanDescription(strings.Join(p.Param.Enum, ", "))
+		if desc != "" {
+			desc += " "
+		}
+		desc += "Values: " + enumStr
+	}
+
+	// Add default if present
+	if p.Param.Default != "" {
+		desc += " (default: " + defaultText(p.Param.Type, p.Param.Default) + ")"
+	}
+
+	return escapeTag(desc)
+}
+
+// SchemaInfo wraps a Schema with generation helpers.
+type SchemaInfo struct {
+	Name          string             // Schema name (e.g., "Video", "VideoStatus")
+	Schema        *Schema            // The schema definition
+	AllSchemas    map[string]*Schema // Reference to all schemas for resolving $ref
+	RequiredSet   map[string]bool    // Set of required property names
+	Request       bool               // Write variant: readOnly properties are dropped
+	SplitSet      map[string]bool    // Schemas that have a separate request variant
+	Enums         *enumRegistry      // Shared enum types (nil when not generating enums)
+	PreserveOrder bool               // Keep properties in document order
+	Options       *GenerateOptions   // Generation options (nil means defaults)
+	AliasOf       string             // Struct name of an identical schema this one aliases (AliasDuplicates)
+	FieldMask     bool               // Whether to generate a FieldMask method (update request bodies)
+	SchemaIDs     map[string]string  // Schema ID -> document key, for $refs naming an ID (see schemaIDIndex)
+}
+
+// NewSchemaInfo creates a SchemaInfo from a schema.
+func NewSchemaInfo(name string, schema *Schema, allSchemas map[string]*Schema) *SchemaInfo {
+	requiredSet := make(map[string]bool)
+	if schema.Annotations != nil {
+		for _, req := range schema.Annotations.Required {
+			requiredSet[req] = true
+		}
+	}
+	return &SchemaInfo{
+		Name:        name,
+		Schema:      schema,
+		AllSchemas:  allSchemas,
+		RequiredSet: requiredSet,
+	}
+}
+
+// StructName returns the Go struct name for this schema.
+func (s *SchemaInfo) StructName() string {
+	if s.Request {
+		return schemaTypeName(s.Name, s.Options) + "Request"
+	}
+	return schemaTypeName(s.Name, s.Options)
+}
+
+// ListItemType returns the item type T when the schema is a list response
+// ({items: [T], nextPageToken: string}, possibly with more fields) built on
+// ListResponse[T]: an alias of it if there are no other fields (see ListAlias),
+// a struct embedding it otherwise. It returns "" when GenericListResponse is off
+// or the shape doesn't match, in which case a plain struct is generated.
+func (s *SchemaInfo) ListItemType() string {
+	if s.Options == nil || !s.Options.GenericListResponse || s.Request || s.FieldMask {
+		return ""
+	}
+	if _, clash := s.AllSchemas["ListResponse"]; clash {
+		return "" // The document already defines a ListResponse type.
+	}
+	items, token := s.Schema.Properties["items"], s.Schema.Properties["nextPageToken"]
+	if items == nil || token == nil || token.Type != "string" || token.Ref != "" {
+		return ""
+	}
+	if items.Type != "array" || items.Items == nil || items.Items.Ref == "" {
+		return ""
+	}
+	key := schemaKey(items.Items.Ref, s.SchemaIDs)
+	ref, ok := s.AllSchemas[key]
+	if !ok || isScalarSchema(ref) {
+		return ""
+	}
+	return schemaTypeName(key, s.Options)
+}
+
+// ListAlias reports whether the schema is generated as an alias of
+// ListResponse[T]: a list response with no fields besides items and nextPageToken.
+func (s *SchemaInfo) ListAlias() bool {
+	return s.ListItemType() != "" && len(s.Schema.Properties) == 2
+}
+
+// Description returns the schema description.
+func (s *SchemaInfo) Description() string {
+	return cleanDescription(s.Schema.Description)
+}
+
+// SliceType returns the Go slice type of a schema that is an array at the top
+// level (e.g. "[]*Video"), generated as a named slice type instead of a struct.
+// It returns "" for any other schema.
+func (s *SchemaInfo) SliceType() string {
+	if !isSliceSchema(s.Schema) {
+		return ""
+	}
+	p := &PropertyInfo{
+		Property:   s.Schema,
+		Required:   true,
+		AllSchemas: s.AllSchemas,
+		Request:    s.Request,
+		SplitSet:   s.SplitSet,
+		Enums:      s.Enums,
+		Options:    s.Options,
+		SchemaIDs:  s.SchemaIDs,
+	}
+	return p.GoType()
+}
+
+// isSliceSchema reports whether schema is an array at the top level, with no
+// properties of its own.
+func isSliceSchema(schema *Schema) bool {
+	return schema.Type == "array" && len(schema.Properties) == 0
+}
+
+// SortedProperties returns schema properties sorted by: required first, then alphabetically,
+// or in document order when PreserveOrder is set.
+func (s *SchemaInfo) SortedProperties() []*PropertyInfo {
+	var props []*PropertyInfo
+	embedsList := s.ListItemType() != ""
+	for name, prop := range s.Schema.Properties {
+		if s.Request && prop.ReadOnly {
+			continue
+		}
+		if embedsList && (name == "items" || name == "nextPageToken") {
+			continue // Promoted from the embedded ListResponse[T]
+		}
+		required := s.RequiredSet[name] || prop.Required
+		props = append(props, &PropertyInfo{
+			Name:       name,
+			Property:   prop,
+			Required:   required,
+			AllSchemas: s.AllSchemas,
+			Request:    s.Request,
+			SplitSet:   s.SplitSet,
+			Enums:      s.Enums,
+			Options:    s.Options,
+			SchemaIDs:  s.SchemaIDs,
+		})
+	}
+	s.renameStructNameFields(props)
+	if s.PreserveOrder {
+		sort.Slice(props, func(i, j int) bool {
+			return declaredLess(s.Schema.PropertyKeys, props[i].Name, props[j].Name)
+		})
+		return props
+	}
+	sort.Slice(props, func(i, j int) bool {
+		if props[i].Required != props[j].Required {
+			return props[i].Required
+		}
+		return props[i].Name < props[j].Name
+	})
+	return props
+}
+
+// renameStructNameFields gives a property whose field name would equal the
+// struct name (property "video" of Video) the name StructName+"Field", numbered
+// if that is taken too. Go allows such fields, but they read like an embedded
+// field of the struct's own type.
+func (s *SchemaInfo) renameStructNameFields(props []*PropertyInfo) {
+	structName := s.StructName()
+	taken := make(map[string]bool, len(props))
+	for _, p := range props {
+		taken[p.FieldName()] = true
+	}
+	for _, p := range props {
+		if p.FieldName() != structName {
+			continue
+		}
+		name := structName + "Field"
+		for i := 2; taken[name]; i++ {
+			name = structName + "Field" + strconv.Itoa(i)
+		}
+		taken[name] = true
+		p.GoName = name
+	}
+}
+
+// PropertyInfo wraps a schema property with generation helpers.
+type PropertyInfo struct {
+	Name       string
+	Property   *Schema
+	Required   bool
+	AllSchemas map[string]*Schema
+	Request    bool              // Property belongs to a request variant
+	SplitSet   map[string]bool   // Schemas that have a separate request variant
+	Enums      *enumRegistry     // Shared enum types (nil when not generating enums)
+	Options    *GenerateOptions  // Generation options (nil means defaults)
+	GoName     string            // Field name overriding the one derived from Name, if set
+	SchemaIDs  map[string]string // Schema ID -> document key, for $refs naming an ID (see schemaIDIndex)
+}
+
+// FieldName returns the Go field name (exported).
+func (p *PropertyInfo) FieldName() string {
+	if p.GoName != "" {
+		return p.GoName
+	}
+	return exportedName(p.Name)
+}
+
+// JSONTag returns the json struct tag. Optional fields get omitempty unless
+// the NoOmitEmpty option is set.
+func (p *PropertyInfo) JSONTag() string {
+	if p.Required || (p.Options != nil && p.Options.NoOmitEmpty) {
+		return p.WireName()
+	}
+	return p.WireName() + ",omitempty"
+}
+
+// CommentLines returns the property description wrapped for a field comment.
+func (p *PropertyInfo) CommentLines() []string {
+	return wrapComment(cleanDescription(p.Property.Description), commentWidth)
+}
+
+// WireName returns the JSON field name, after applying JSONTagCase.
+func (p *PropertyInfo) WireName() string {
+	return applyJSONTagCase(p.Name, p.Options)
+}
+
+// GoType returns the Go type for this property.
+func (p *PropertyInfo) GoType() string {
+	return p.resolveType(p.Property, !p.Required)
+}
+
+// resolveType resolves the Go type for a schema, handling refs, arrays, objects, etc.
+func (p *PropertyInfo) resolveType(schema *Schema, optional bool) string {
+	// Handle $ref
+	if schema.Ref != "" {
+		// Reference to another schema - use its exported name
+		ref := schemaKey(schema.Ref, p.SchemaIDs)
+		refType := schemaTypeName(ref, p.Options)
+		// Check if the referenced schema is a simple type (wrapper)
+		if refSchema, ok := p.AllSchemas[ref]; ok && isScalarSchema(refSchema) {
+			if p.isBytes(refSchema) {
+				return "[]byte"
+			}
+			if t := p.Enums.typeFor(refSchema.Type, refSchema.Enum); t != "" {
+				return optionalScalar(t, optional, p.Options)
+			}
+			return p.freeform(optionalScalar(scalarGoType(refSchema.Type, refSchema.Format, optional, p.Options), optional, p.Options))
+		}
+		if p.Request && p.SplitSet[ref] {
+			refType += "Request"
+		}
+		if refSchema, ok := p.AllSchemas[ref]; ok && isSliceSchema(refSchema) {
+			return refType // A named slice type is nil when absent; no pointer needed.
+		}
+		return "*" + refType
+	}
+
+	switch schema.Type {
+	case "array":
+		if schema.Items != nil {
+			elemType := p.resolveType(schema.Items, false) // array elements aren't individually optional
+			return "[]" + elemType
+		}
+		return "[]" + anyType(p.Options)
+	case "object":
+		if schema.AdditionalProperties != nil {
+			valueType := p.resolveType(schema.AdditionalProperties, false)
+			return "map[string]" + valueType
+		}
+		// Inline object - use any since we can't generate anonymous structs well
+		return p.freeform("map[string]" + anyType(p.Options))
+	default:
+		if p.isBytes(schema) {
+			return "[]byte"
+		}
+		if t := p.Enums.typeFor(schema.Type, schema.Enum); t != "" {
+			return optionalScalar(t, optional, p.Options)
+		}
+		return p.freeform(optionalScalar(scalarGoType(schema.Type, schema.Format, optional, p.Options), optional, p.Options))
+	}
+}
+
+// enumValues returns the allowed values of the property, looking through arrays
+// and references to scalar schemas. It is nil if the property is not an enum.
+func (p *PropertyInfo) enumValues() []string {
+	schema := p.Property
+	if schema.Type == "array" && schema.Items != nil {
+		schema = schema.Items
+	}
+	if ref, ok := p.AllSchemas[schemaKey(schema.Ref, p.SchemaIDs)]; ok && schema.Ref != "" && isScalarSchema(ref) {
+		schema = ref
+	}
+	return schema.Enum
+}
+
+// isBytes reports whether schema is a base64 string to be generated as []byte,
+// which encoding/json already encodes as base64.
+func (p *PropertyInfo) isBytes(schema *Schema) bool {
+	return p.Options != nil && p.Options.ByteAsBytes && schema.Type == "string" && schema.Format == "byte"
+}
+
+// freeform replaces the opaque any and map[string]any types with json.RawMessage
+// when RawMessageForAny is set, so the raw bytes can be decoded later.
+func (p *PropertyInfo) freeform(goType string) string {
+	if unknown := anyType(p.Options); p.Options != nil && p.Options.RawMessageForAny && (goType == unknown || goType == "map[string]"+unknown) {
+		return "json.RawMessage"
+	}
+	return goType
+}
+
+// Constraints returns size constraints (minItems, maxLength, ...) formatted for
+// the jsonschema tag, e.g. "minItems=1,maxItems=10". Empty if there are none.
+func (p *PropertyInfo) Constraints() string {
+	var parts []string
+	for _, c := range []struct {
+		name  string
+		value json.Number
+	}{
+		{"minItems", p.Property.MinItems},
+		{"maxItems", p.Property.MaxItems},
+		{"minLength", p.Property.MinLength},
+		{"maxLength", p.Property.MaxLength},
+		{"minProperties", p.Property.MinProperties},
+		{"maxProperties", p.Property.MaxProperties},
+	} {
+		if c.value != "" {
+			parts = append(parts, c.name+"="+c.value.String())
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// SchemaTag returns the jsonschema tag value: the description followed by any constraints.
+func (p *PropertyInfo) SchemaTag() string {
+	desc, constraints := escapeTag(p.SchemaDescription()), p.Constraints()
+	if desc == "" || constraints == "" {
+		return desc + constraints
+	}
+	return desc + "," + constraints
+}
+
+// SchemaDescription returns the jsonschema description for this property.
+func (p *PropertyInfo) SchemaDescription() string {
+	desc := truncateWords(cleanDescription(p.Property.Description), p.Options.maxFieldDescLen())
+
+	// Add enum values to description if present
+	if len(p.Property.Enum) > 0 {
+		enumStr := cleanDescription(strings.Join(p.Property.Enum, ", "))
+		if desc != "" {
+			desc += " "
+		}
+		desc += "Values: " + enumStr
+	}
+
+	// Add default if present
+	if p.Property.Default != "" {
+		desc += " (default: " + defaultText(p.Property.Type, p.Property.Default) + ")"
+	}
+
+	// Add read-only indicator
+	if p.Property.ReadOnly {
+		desc += " (read-only)"
+	}
+
+	return desc
+}
+
+// isScalarSchema reports whether a schema is a plain scalar wrapper. Schemas that
+// carry properties or additionalProperties are objects even if "type" is omitted.
+func isScalarSchema(schema *Schema) bool {
+	if len(schema.Properties) > 0 || schema.AdditionalProperties != nil {
+		return false
+	}
+	return schema.Type != "" && schema.Type != "object" && schema.Type != "array"
+}
+
+// cleanDescription sanitizes a description for use in Go comments, raw string
+// literals and (through escapeTag) struct tags: newlines, tabs and other control
+// characters become spaces, quotes and backticks become single quotes, and byte
+// order marks are dropped.
+func cleanDescription(desc string) string {
+	desc = strings.ToValidUTF8(desc, "")
+	desc = strings.Map(func(r rune) rune {
+		switch {
+		case r == '"' || r == '`':
+			return '\''
+		case r == '\uFEFF':
+			return -1
+		case unicode.IsControl(r) || r == '\u2028' || r == '\u2029':
+			return ' '
+		}
+		return r
+	}, desc)
+	desc = strings.TrimSpace(desc)
+	// Collapse multiple spaces
+	for strings.Contains(desc, "  ") {
+		desc = strings.ReplaceAll(desc, "  ", " ")
+	}
+	return desc
+}
+
+// markdownEscaper backslash-escapes backslashes and the characters that start
+// Markdown emphasis, headings, links, HTML, strikethrough and tables.
+// cleanDescription has already replaced backticks.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "#", `\#`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "~", `\~`, "|", `\|`,
+)
+
+// escapeTag escapes the backslashes of a cleaned description for a struct tag
+// value, which reflect.StructTag unquotes like a Go string literal.
+func escapeTag(desc string) string {
+	return strings.ReplaceAll(desc, `\`, `\\`)
+}
+
+// escapeMarkdown escapes Markdown syntax in a cleaned description so hosts
+// rendering it as Markdown show the text as written.
+func escapeMarkdown(desc string) string {
+	return markdownEscaper.Replace(desc)
+}
+
+// Helper functions
+
+// commentWidth is the maximum length of a wrapped field comment line, excluding "// ".
+const commentWidth = 76
+
+// wrapComment splits text into lines of at most width characters at word
+// boundaries. Words longer than width get a line of their own.
+func wrapComment(text string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// maxFieldDescLen returns the MaxFieldDescriptionLen option, or 0 for nil options.
+func (o *GenerateOptions) maxFieldDescLen() int {
+	if o == nil {
+		return 0
+	}
+	return o.MaxFieldDescriptionLen
+}
+
+// truncateWords shortens text to at most limit bytes, cutting at a word boundary
+// and marking the cut with "...". A first word longer than limit is cut mid-word.
+// A limit of 0 or less leaves text unchanged.
+func truncateWords(text string, limit int) string {
+	if limit <= 0 || len(text) <= limit {
+		return text
+	}
+	const ellipsis = "..."
+	var out string
+	for _, word := range strings.Fields(text) {
+		next := word
+		if out != "" {
+			next = out + " " + word
+		}
+		if len(next)+len(ellipsis) > limit {
+			break
+		}
+		out = next
+	}
+	if out == "" {
+		out = strings.ToValidUTF8(text[:max(limit-len(ellipsis), 0)], "")
+	}
+	return strings.TrimRight(out, ",;:") + ellipsis
+}
+
+// applyJSONTagCase converts a wire name to the casing selected by opts.JSONTagCase.
+func applyJSONTagCase(name string, opts *GenerateOptions) string {
+	if opts == nil {
+		return name
+	}
+	switch opts.JSONTagCase {
+	case JSONTagCaseSnake:
+		return snakeCase(name)
+	case JSONTagCaseCamel:
+		return camelCase(name)
+	}
+	return name
+}
+
+// snakeCase converts "maxResults" to "max_results" and "videoHTMLId" to "video_html_id".
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if r == '-' {
+			r = '_'
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// camelCase converts "max_results" or "max-results" to "maxResults". Names that
+// are already camelCase are returned unchanged.
+func camelCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if r == '_' || r == '-' {
+			upper = b.Len() > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// schemaTypeName returns the Go type name of the schema named ref, with the
+// SchemaPrefix of opts (which may be nil).
+func schemaTypeName(ref string, opts *GenerateOptions) string {
+	if opts == nil {
+		return exportedName(ref)
+	}
+	return opts.SchemaPrefix + exportedName(ref)
+}
+
+func exportedName(s string) string {
+	if s == "" {
+		return ""
+	}
+	// Handle camelCase and snake_case
+	s = strings.ReplaceAll(s, "_", " ")
+	s = strings.ReplaceAll(s, "-", " ")
+
+	words := strings.Fields(s)
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	result := strings.Join(words, "")
+
+	// Handle acronyms
+	result = strings.ReplaceAll(result, "Id", "ID")
+	result = strings.ReplaceAll(result, "Url", "URL")
+	result = strings.ReplaceAll(result, "Http", "HTTP")
+	result = strings.ReplaceAll(result, "Api", "API")
+
+	// Ensure first char is uppercase
+	if result != "" {
+		runes := []rune(result)
+		runes[0] = unicode.ToUpper(runes[0])
+		result = string(runes)
+	}
+
+	// Identifiers can't start with a digit; prefix a letter (not "_", which
+	// would make the field unexported and invisible to encoding/json).
+	if result != "" && unicode.IsDigit([]rune(result)[0]) {
+		result = "X" + result
+	}
+
+	return result
+}
+
+// paramGoType returns the Go type of a parameter. required is the effective
+// requiredness (see ParamInfo.Required), which can differ from p.Required.
+func paramGoType(p *Parameter, required bool, opts *GenerateOptions) string {
+	optional := !required
+	if p.Repeated {
+		return "[]" + scalarGoType(p.Type, p.Format, false, opts) // array elements aren't optional
+	}
+	return scalarGoType(p.Type, p.Format, optional, opts)
+}
+
+// integerGoTypes and numberGoTypes map the formats of Discovery integer and
+// number types to Go types. Formats missing from them get the "" entry.
+var (
+	integerGoTypes = map[string]string{"": "int64", "int32": "int32", "uint32": "uint32", "int64": "int64", "uint64": "uint64"}
+	numberGoTypes  = map[string]string{"": "float64", "float": "float32", "double": "float64"}
+)
+
+// scalarGoType returns the Go type for a scalar Discovery Document type.
+// If optional is true and it's a boolean, returns *bool to distinguish absent from false.
+func scalarGoType(typ, typeFormat string, optional bool, opts *GenerateOptions) string {
+	switch typ {
+	case "string":
+		return "string"
+	case "integer":
+		if t, ok := integerGoTypes[typeFormat]; ok {
+			return t
+		}
+		return integerGoTypes[""]
+	case "number":
+		if t, ok := numberGoTypes[typeFormat]; ok {
+			return t
+		}
+		return numberGoTypes[""]
+	case "boolean":
+		if optional {
+			return "*bool"
+		}
+		return "bool"
+	default: // "any" and types without a Go mapping
+		return anyType(opts)
+	}
+}
+
+// declaredLess orders names by their position in keys. Names missing from keys
+// (e.g. hand-built documents or merged common parameters) sort last, alphabetically.
+func declaredLess(keys []string, a, b string) bool {
+	ia, ib := indexOf(keys, a), indexOf(keys, b)
+	if ia != ib {
+		if ia == -1 {
+			return false
+		}
+		if ib == -1 {
+			return true
+		}
+		return ia < ib
+	}
+	return a < b
+}
+
+// optionalScalar turns an optional scalar Go type into a pointer when
+// OptionalAsPointer is set, or for numeric types when OptionalNumbersAsPointer
+// is. Types that are already pointers and anyType are unchanged.
+func optionalScalar(goType string, optional bool, opts *GenerateOptions) string {
+	if !optional || opts == nil || !(opts.OptionalAsPointer || opts.OptionalNumbersAsPointer && isNumericGoType(goType)) {
+		return goType
+	}
+	if strings.HasPrefix(goType, "*") || goType == anyType(opts) {
+		return goType
+	}
+	return "*" + goType
+}
+
+// isNumericGoType reports whether goType is one of the integer and floating-point
+// types scalarGoType maps Discovery integers and numbers to.
+func isNumericGoType(goType string) bool {
+	switch goType {
+	case "int32", "uint32", "int64", "uint64", "float32", "float64":
+		return true
+	}
+	return false
+}
+
+func indexOf(slice []string, s string) int {
+	for i, v := range slice {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// collectSchemas collects all schemas needed by the given methods, including dependencies.
+// Returns schemas in dependency order (dependencies first).
+func collectSchemas(methods []*MethodInfo, allSchemas map[string]*Schema, ids map[string]string) []*SchemaInfo {
+	needed := make(map[string]bool)
+
+	// Find all directly referenced schemas
+	for _, m := range methods {
+		if m.Method.Request != nil && m.Method.Request.Ref != "" {
+			collectSchemaRefs(m.Method.Request.Ref, allSchemas, ids, needed)
+		}
+		if m.Method.Response != nil && m.Method.Response.Ref != "" {
+			collectSchemaRefs(m.Method.Response.Ref, allSchemas, ids, needed)
+		}
+	}
+	return neededSchemas(needed, allSchemas)
+}
+
+// collectRootSchemas collects the named schemas and everything they transitively
+// reference, regardless of any method. Every root must name a schema of the document.
+func collectRootSchemas(roots []string, allSchemas map[string]*Schema, ids map[string]string) ([]*SchemaInfo, error) {
+	needed := make(map[string]bool)
+	for _, root := range roots {
+		if _, ok := allSchemas[schemaKey(root, ids)]; !ok {
+			return nil, fmt.Errorf("root schema not found: %s", root)
+		}
+		collectSchemaRefs(root, allSchemas, ids, needed)
+	}
+	return neededSchemas(needed, allSchemas), nil
+}
+
+// neededSchemas converts a set of collected schema names to SchemaInfos.
+func neededSchemas(needed map[string]bool, allSchemas map[string]*Schema) []*SchemaInfo {
+	// Convert to SchemaInfo list, sorted by name for deterministic output
+	var names []string
+	for name := range needed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []*SchemaInfo
+	for _, name := range names {
+		if schema, ok := allSchemas[name]; ok {
+			result = append(result, NewSchemaInfo(name, schema, allSchemas))
+		}
+	}
+
+	return result
+}
+
+// collectAllSchemas returns every schema in the document, sorted by name.
+func collectAllSchemas(allSchemas map[string]*Schema) []*SchemaInfo {
+	names := sortedKeys(allSchemas)
+	result := make([]*SchemaInfo, 0, len(names))
+	for _, name := range names {
+		result = append(result, NewSchemaInfo(name, allSchemas[name], allSchemas))
+	}
+	return result
+}
+
+// schemaIDIndex maps the ID of every schema whose document key differs from it
+// to that key. Some documents key schemas differently from their id, and a $ref
+// may name either; see schemaKey.
+func schemaIDIndex(allSchemas map[string]*Schema) map[string]string {
+	ids := make(map[string]string)
+	for key, schema := range allSchemas {
+		if schema != nil && schema.ID != "" && schema.ID != key {
+			if _, isKey := allSchemas[schema.ID]; !isKey {
+				ids[schema.ID] = key
+			}
+		}
+	}
+	return ids
+}
+
+// schemaKey returns the document key a $ref resolves to: the ref itself when
+// it is a key, else the key of the schema with that ID, else the ref unchanged.
+func schemaKey(ref string, ids map[string]string) string {
+	if key, ok := ids[ref]; ok {
+		return key
+	}
+	return ref
+}
+
+// collectSchemaRefs recursively collects a schema and all its dependencies.
+func collectSchemaRefs(schemaName string, allSchemas map[string]*Schema, ids map[string]string, needed map[string]bool) {
+	schemaName = schemaKey(schemaName, ids)
+	if needed[schemaName] {
+		return // Already collected
+	}
+
+	schema, ok := allSchemas[schemaName]
+	if !ok {
+		return // Schema not found
+	}
+
+	needed[schemaName] = true
+
+	// Collect property references
+	for _, prop := range schema.Properties {
+		collectSchemaRefsFromSchema(prop, allSchemas, ids, needed)
+	}
+
+	// Collect items references (for arrays)
+	if schema.Items != nil {
+		collectSchemaRefsFromSchema(schema.Items, allSchemas, ids, needed)
+	}
+
+	// Collect additionalProperties references (for maps)
+	if schema.AdditionalProperties != nil {
+		collectSchemaRefsFromSchema(schema.AdditionalProperties, allSchemas, ids, needed)
+	}
+}
+
+// collectSchemaRefsFromSchema collects schema references from a schema definition.
+func collectSchemaRefsFromSchema(schema *Schema, allSchemas map[string]*Schema, ids map[string]string, needed map[string]bool) {
+	if schema.Ref != "" {
+		collectSchemaRefs(schema.Ref, allSchemas, ids, needed)
+	}
+	for _, prop := range schema.Properties {
+		collectSchemaRefsFromSchema(prop, allSchemas, ids, needed)
+	}
+	if schema.Items != nil {
+		collectSchemaRefsFromSchema(schema.Items, allSchemas, ids, needed)
+	}
+	if schema.AdditionalProperties != nil {
+		collectSchemaRefsFromSchema(schema.AdditionalProperties, allSchemas, ids, needed)
+	}
+}
+
+// collectRequestSchemas collects request variants for schemas reachable from request
+// bodies that (transitively) contain readOnly properties. Schemas without readOnly
+// properties are identical in both directions and are left to collectSchemas.
+func collectRequestSchemas(methods []*MethodInfo, allSchemas map[string]*Schema, ids map[string]string) []*SchemaInfo {
+	reachable := make(map[string]bool)
+	for _, m := range methods {
+		if m.Method.Request != nil && m.Method.Request.Ref != "" {
+			collectSchemaRefs(m.Method.Request.Ref, allSchemas, ids, reachable)
+		}
+	}
+
+	memo := make(map[string]bool)
+	split := make(map[string]bool)
+	var names []string
+	for name := range reachable {
+		if containsReadOnly(name, allSchemas, ids, memo, make(map[string]bool)) {
+			split[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var result []*SchemaInfo
+	for _, name := range names {
+		info := NewSchemaInfo(name, allSchemas[name], allSchemas)
+		info.Request = true
+		info.SplitSet = split
+		result = append(result, info)
+	}
+	return result
+}
+
+// containsReadOnly reports whether a named schema has readOnly properties,
+// directly or through any schema it references.
+func containsReadOnly(name string, allSchemas map[string]*Schema, ids map[string]string, memo, visiting map[string]bool) bool {
+	name = schemaKey(name, ids)
+	if v, ok := memo[name]; ok {
+		return v
+	}
+	schema, ok := allSchemas[name]
+	if !ok || visiting[name] {
+		return false
+	}
+	visiting[name] = true
+	result := schemaContainsReadOnly(schema, allSchemas, ids, memo, visiting)
+	memo[name] = result
+	return result
+}
+
+func schemaContainsReadOnly(schema *Schema, allSchemas map[string]*Schema, ids map[string]string, memo, visiting map[string]bool) bool {
+	if schema.Ref != "" {
+		return containsReadOnly(schema.Ref, allSchemas, ids, memo, visiting)
+	}
+	for _, prop := range schema.Properties {
+		if prop.ReadOnly || schemaContainsReadOnly(prop, allSchemas, ids, memo, visiting) {
+			return true
+		}
+	}
+	if schema.Items != nil && schemaContainsReadOnly(schema.Items, allSchemas, ids, memo, visiting) {
+		return true
+	}
+	if schema.AdditionalProperties != nil && schemaContainsReadOnly(schema.AdditionalProperties, allSchemas, ids, memo, visiting) {
+		return true
+	}
+	return false
+}
+
+var codeTemplate = template.Must(template.New("mcp").Parse(`
+{{- define "header" -}}
+{{- range .BuildLines}}
+{{.}}
+{{- end}}
+{{- if .BuildLines}}
+
+{{end -}}
+// Code generated by google-discovery-mcp. DO NOT EDIT.
+// Source: {{.APIName}} {{.APIVersion}}
+// API: {{.APITitle}}
+{{- end}}
+
+{{- define "file" -}}
+{{template "header" .}}
+
+package {{.PackageName}}
+{{template "imports" (.ImportGroups true true)}}
+{{- template "types" .}}
+{{template "apiinfo" .}}
+{{template "definitions" .}}
+{{template "scopes" .}}
+{{template "handlers" .}}
+{{template "dispatch" .}}
+{{template "registration" .}}
+{{- if .RegistryTypes}}
+{{template "registrytypes"}}
+{{- end}}
+{{- end}}
+
+{{- define "reexport" -}}
+{{template "header" .}}
+
+package {{.Parent}}
+
+import {{.ImportSpec}}
+{{- if .Types}}
+
+// Types generated in package {{.Subpackage}}.
+type (
+{{- range .Types}}
+	{{.}} = {{$.Subpackage}}.{{.}}
+{{- end}}
+)
+{{- end}}
+{{- if .Consts}}
+
+// Constants generated in package {{.Subpackage}}.
+const (
+{{- range .Consts}}
+	{{.}} = {{$.Subpackage}}.{{.}}
+{{- end}}
+)
+{{- end}}
+{{- if .Vars}}
+
+// Variables and functions generated in package {{.Subpackage}}.
+var (
+{{- range .Vars}}
+	{{.}} = {{$.Subpackage}}.{{.}}
+{{- end}}
+)
+{{- end}}
+{{- end}}
+
+{{- define "testfile" -}}
+{{template "header" .}}
+
+package {{.PackageName}}
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestGeneratedTypesRoundTrip checks that every generated struct survives a JSON
+// round trip, catching malformed struct tags.
+func TestGeneratedTypesRoundTrip(t *testing.T) {
+	types := map[string]{{.AnyType}}{
+{{- if not (or .SchemaOnly .InputSchemaMap)}}
+{{- range .Methods}}
+		"{{.StructName}}": &{{.StructName}}{},
+{{- end}}
+{{- end}}
+{{- if .GenerateSchema}}
+{{- range .SchemasToGen}}
+		"{{.StructName}}": &{{.StructName}}{},
+{{- end}}
+{{- end}}
+	}
+	for name, v := range types {
+		t.Run(name, func(t *testing.T) {
+			data, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatalf("unmarshal %s: %v", data, err)
+			}
+		})
+	}
+}
+{{- end}}
+
+{{- define "schemasfile" -}}
+{{template "header" .}}
+
+package {{.PackageName}}
+{{template "imports" (.ImportGroups true false)}}
+{{- template "schematypes" .}}
+{{- template "enumtypes" .}}
+{{- end}}
+
+{{- define "scopesfile" -}}
+{{template "header" .}}
+
+package {{.PackageName}}
+{{template "scopes" .}}
+{{- end}}
+
+{{- define "scopes"}}
+{{- if .Scopes}}
+// OAuth scopes used by the generated tools.
+const (
+{{- range .Scopes}}
+	{{.ConstName}} = {{printf "%q" .URL}}
+{{- end}}
+)
+
+// GeneratedToolScopes maps each tool name to the OAuth scopes that authorize it.
+var GeneratedToolScopes = map[string][]string{
+{{- range .Methods}}
+{{- if .Method.Scopes}}
+	"{{.ToolName}}": { {{- range $i, $s := .Method.Scopes}}{{if $i}}, {{end}}{{$.ScopeConst $s}}{{end -}} },
+{{- end}}
+{{- end}}
+}
+{{end}}
+{{- end}}
+
+{{- define "tools" -}}
+{{template "header" .}}
+
+package {{.PackageName}}
+{{template "imports" (.ImportGroups (not .SeparateSchemaFile) true)}}
+{{- if .SeparateSchemaFile}}
+{{- template "argtypes" .}}
+{{- else}}
+{{- template "types" .}}
+{{- end}}
+{{template "definitions" .}}
+{{template "handlers" .}}
+{{template "dispatch" .}}
+{{template "registration" .}}
+{{- end}}
+
+{{- define "imports"}}
+{{- with .}}
+import (
+{{- range $i, $group := .}}
+{{- if $i}}
+{{end}}
+{{- range $group}}
+	{{.}}
+{{- end}}
+{{- end}}
+)
+{{end}}
+{{- end}}
+
+{{- define "doc" -}}
+{{template "header" .}}
+
+// Package {{.PackageName}} contains MCP tool types generated from the {{.APITitle}} ({{.APIName}} {{.APIVersion}}).
+package {{.PackageName}}
+{{template "apiinfo" .}}
+{{- end}}
+
+{{- define "types"}}
+{{- template "schematypes" .}}
+{{- template "enumtypes" .}}
+{{- template "argtypes" .}}
+{{- end}}
+
+{{- define "schematypes"}}
+{{- if .GenerateSchema}}
+// =============================================================================
+// Schema Types (Request/Response Bodies)
+// =============================================================================
+{{- if .HasGenericList}}
+
+// ListResponse is a page of results from a list method.
+type ListResponse[T any] struct {
+	Items         []*T   ` + "`" + `json:"items,omitempty"{{if not .OmitSchemaTags}} jsonschema:"The items on this page"{{end}}` + "`" + `
+	NextPageToken string ` + "`" + `json:"nextPageToken,omitempty"{{if not .OmitSchemaTags}} jsonschema:"Token for the next page; empty on the last page"{{end}}` + "`" + `
+}
+{{if .GenerateMarshalJSON}}
+// MarshalJSON encodes the page, omitting empty fields.
+func (v ListResponse[T]) MarshalJSON() ([]byte, error) {
+	return marshalNonZero(v)
+}
+{{end}}
+{{- end}}
+{{range .SchemasToGen}}
+{{- if .AliasOf}}
+// {{.StructName}} - {{.Description}}
+type {{.StructName}} = {{.AliasOf}}
+{{else if .ListAlias}}
+// {{.StructName}} - {{.Description}}
+type {{.StructName}} = ListResponse[{{.ListItemType}}]
+{{else if .SliceType}}
+// {{.StructName}} - {{.Description}}
+type {{.StructName}} {{.SliceType}}
+{{else}}
+// {{.StructName}} - {{.Description}}
+type {{.StructName}} struct {
+{{- with .ListItemType}}
+	ListResponse[{{.}}]
+{{- end}}
+{{- range .SortedProperties}}
+{{- if $.FieldComments}}{{range .CommentLines}}
+	// {{.}}{{end}}{{end}}
+	{{.FieldName}} {{.GoType}} ` + "`" + `json:"{{.JSONTag}}"{{if not $.OmitSchemaTags}} jsonschema:"{{.SchemaTag}}"{{end}}{{if $.ValidateTags}}{{with .ValidateTag}} validate:"{{.}}"{{end}}{{end}}` + "`" + `
+{{- end}}
+}
+{{if $.GenerateMarshalJSON}}
+// MarshalJSON encodes {{.StructName}}, omitting nil pointers and zero-value structs.
+func (v {{.StructName}}) MarshalJSON() ([]byte, error) {
+	return marshalNonZero(v)
+}
+{{end}}
+{{- if .FieldMask}}
+// FieldMask returns the API names of the fields set in v, comma-separated, for
+// the updateMask parameter of update methods.
+func (v *{{.StructName}}) FieldMask() string {
+	return fieldMask(v{{range .SortedProperties}}, "{{.WireName}}"{{end}})
+}
+{{end}}
+{{- end}}
+{{- end}}
+{{- if .HasFieldMask}}
+// fieldMask returns the names of the non-zero fields of the struct pointed to
+// by v, comma-separated. names holds the API name of every field, in order.
+func fieldMask(v {{.AnyType}}, names ...string) string {
+	rv := reflect.ValueOf(v).Elem()
+	var set []string
+	for i, name := range names {
+		if !rv.Field(i).IsZero() {
+			set = append(set, name)
+		}
+	}
+	return strings.Join(set, ",")
+}
+{{end}}
+{{- if .GenerateMarshalJSON}}
+// marshalNonZero encodes the struct v as a JSON object, leaving out fields that
+// are nil, zero-value structs or pointers to them, and empty omitempty fields.
+func marshalNonZero(v {{.AnyType}}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+	fields := make(map[string]json.RawMessage)
+	for i := 0; i < rt.NumField(); i++ {
+		name, opts, _ := strings.Cut(rt.Field(i).Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+{{- if .HasGenericList}}
+		if rt.Field(i).Anonymous && name == "" {
+			// Merge the fields of an embedded ListResponse[T].
+			raw, err := json.Marshal(fv.Interface())
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				return nil, err
+			}
+			continue
+		}
+{{- end}}
+		if fv.IsZero() && (strings.Contains(opts, "omitempty") || fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Struct) {
+			continue
+		}
+		if fv.Kind() == reflect.Pointer && fv.Elem().Kind() == reflect.Struct && fv.Elem().IsZero() {
+			continue
+		}
+		if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Map) && fv.Len() == 0 && strings.Contains(opts, "omitempty") {
+			continue
+		}
+		raw, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = raw
+	}
+	return json.Marshal(fields)
+}
+{{end}}{{end}}
+{{- end}}
+
+{{- define "enumtypes"}}
+{{- if .Enums}}
+// =============================================================================
+// Enum Types
+// =============================================================================
+{{range $enum := .Enums}}
+// {{$enum.TypeName}} is an enum shared by all fields with these values.
+type {{$enum.TypeName}} string
+
+const (
+{{- range .Constants}}
+{{- if .Description}}
+	// {{.Description}}
+{{- end}}
+	{{.Name}} {{$enum.TypeName}} = {{printf "%q" .Value}}
+{{- end}}
+)
+{{end}}{{end}}
+{{- end}}
+
+{{- define "argtypes"}}
+{{- if not (or .SchemaOnly .InputSchemaMap)}}
 // =============================================================================
 // Tool Argument Types (URL Parameters)
 // =============================================================================
 {{range .Methods}}
 // {{.StructName}} are the arguments for {{.ToolName}}.
 // {{.Description}}
+{{- if $.GenerateExamples}}
+{{.Example}}
+{{- end}}
 type {{.StructName}} struct {
+{{- if .TakesNoInput}}
+	// {{.ToolName}} takes no input: the method has no parameters and no request body.
+{{- end}}
+{{- range .SortedParams}}
+{{- if $.FieldComments}}{{range .CommentLines}}
+	// {{.}}{{end}}{{end}}
+	{{.FieldName}} {{.GoType}} ` + "`" + `json:"{{.JSONTag}}"{{if not $.OmitSchemaTags}} jsonschema:"{{.SchemaDescription}}"{{end}}{{if $.ValidateTags}}{{with .ValidateTag}} validate:"{{.}}"{{end}}{{end}}` + "`" + `
+{{- end}}
+}
+{{if $.GenerateInputSchema}}
+// InputSchema returns the JSON Schema for {{.StructName}}.
+func ({{.StructName}}) InputSchema() map[string]{{$.AnyType}} {
+	return {{.InputSchemaLiteral}}
+}
+{{end}}
+{{- if $.GenerateJSONSchema}}
+// JSONSchema returns the JSON Schema for {{.StructName}}, built directly so
+// jsonschema.Reflect need not reflect over its struct tags.
+func ({{.StructName}}) JSONSchema() *jsonschema.Schema {
+	properties := jsonschema.NewProperties()
 {{- range .SortedParams}}
-	{{.FieldName}} {{.GoType}} ` + "`" + `json:"{{.JSONTag}}" jsonschema:"{{.SchemaDescription}}"` + "`" + `
+	properties.Set({{printf "%q" .WireName}}, {{.JSONSchemaLiteral}})
+{{- end}}
+	return &jsonschema.Schema{
+		Type:       "object",
+		Properties: properties,
+{{- with .RequiredArgsLiteral}}
+		Required:   {{.}},
+{{- end}}
+	}
+}
+{{end}}
+{{- if $.GenerateDescribe}}
+// Describe returns the MCP tool description of {{.ToolName}}.
+func ({{.StructName}}) Describe() string {
+	return {{printf "%q" .DescribeText}}
+}
+{{end}}
+{{- if $.GenerateHTTPInfo}}
+// HTTPMethod returns the HTTP method of {{.ToolName}}.
+func ({{.StructName}}) HTTPMethod() string {
+	return {{printf "%q" .Method.HTTPMethod}}
+}
+
+// PathTemplate returns the path template of {{.ToolName}}, relative to the
+// service path. Placeholders name the json fields of the args; "{+name}"
+// values keep their slashes.
+func ({{.StructName}}) PathTemplate() string {
+	return {{printf "%q" .PathTemplate}}
+}
+
+// FlatPathTemplate returns the flatPath of {{.ToolName}}, with one placeholder
+// per path segment, or "" when the document has none.
+func ({{.StructName}}) FlatPathTemplate() string {
+	return {{printf "%q" .FlatPathTemplate}}
+}
+{{end}}
+{{- if $.GenerateURLValues}}
+// ToURLValues returns the query parameters of {{.ToolName}} that are set,
+// repeated parameters once per element. Path parameters are left out.
+func (a {{.StructName}}) ToURLValues() url.Values {
+	q := url.Values{}
+{{- range .SortedParams}}{{with .URLValuesStmt}}
+	{{.}}{{end}}{{end}}
+	return q
+}
+{{end}}
+{{- if $.GenerateScopes}}
+// Scopes returns the OAuth scopes that authorize {{.ToolName}}; any one of them suffices.
+func ({{.StructName}}) Scopes() []string {
+{{- if .Method.Scopes}}
+	return []string{ {{- range $i, $s := .Method.Scopes}}{{if $i}}, {{end}}{{$.ScopeConst $s}}{{end -}} }
+{{- else}}
+	return nil
+{{- end}}
+}
+{{end}}{{end}}
+{{- end}}
+{{- end}}
+
+{{- define "apiinfo"}}
+// GeneratedAPIInfo describes the API the tools were generated from.
+var GeneratedAPIInfo = struct {
+	Name        string
+	Version     string
+	Title       string
+	RootURL     string
+	ServicePath string
+	DocsLink    string
+	Revision    string // Discovery document revision; regenerate when the published one changes
+	Etag        string // Discovery document etag, if it had one
+}{
+	Name:        {{printf "%q" .APIName}},
+	Version:     {{printf "%q" .APIVersion}},
+	Title:       {{printf "%q" .APITitle}},
+	RootURL:     {{printf "%q" .RootURL}},
+	ServicePath: {{printf "%q" .ServicePath}},
+	DocsLink:    {{printf "%q" .DocsLink}},
+	Revision:    {{printf "%q" .Revision}},
+	Etag:        {{printf "%q" .Etag}},
+}
+{{- end}}
+
+{{- define "handlers"}}
+{{- if .GenerateHandlers}}
+// =============================================================================
+// Tool Handlers
+// =============================================================================
+
+// ToolHandler handles an MCP tool call.
+type ToolHandler func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error)
+
+// RegisterTools calls register for every generated tool with its description and handler.
+func RegisterTools(register func(name, description string, handler ToolHandler)) {
+{{- range .Methods}}
+	register("{{.ToolName}}", ` + "`" + `{{.ToolDescription}}` + "`" + `, {{.HandlerName}})
+{{- end}}
+}
+
+// bindArguments decodes a tool call's arguments into args.
+func bindArguments(req mcp.CallToolRequest, args {{.AnyType}}) error {
+	raw, err := json.Marshal(req.Params.Arguments)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, args)
+}
+{{range .Methods}}
+// {{.HandlerName}} handles {{.ToolName}}.
+func {{.HandlerName}}(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args {{.StructName}}
+	if err := bindArguments(req, &args); err != nil {
+		return mcp.NewToolResultError("invalid arguments: " + err.Error()), nil
+	}
+	// TODO: implement {{.ToolName}}.
+	return mcp.NewToolResultError("{{.ToolName}} is not implemented"), nil
+}
+{{end}}{{end}}
+{{- end}}
+
+{{- define "dispatch"}}
+{{- if .GenerateDispatch}}
+// ToolFuncs holds a function per tool, called by Dispatch with the tool's
+// decoded arguments. Set a field to implement its tool; Dispatch reports tools
+// whose field is nil as not implemented.
+type ToolFuncs struct {
+{{- range .Methods}}
+	{{.FuncName}} func(ctx context.Context, args {{.StructName}}) ({{$.AnyType}}, error)
+{{- end}}
+}
+
+// Dispatch decodes raw into the args struct of the named tool and calls the
+// tool's function in f. Empty raw arguments decode as the zero args.
+func (f *ToolFuncs) Dispatch(ctx context.Context, name string, raw json.RawMessage) ({{.AnyType}}, error) {
+	switch name {
+{{- range .Methods}}
+	case "{{.ToolName}}":
+		if f.{{.FuncName}} == nil {
+			return nil, fmt.Errorf("%s is not implemented", name)
+		}
+		var args {{.StructName}}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("%s: invalid arguments: %w", name, err)
+			}
+		}
+		return f.{{.FuncName}}(ctx, args)
+{{- end}}
+	default:
+		return nil, fmt.Errorf("unknown tool %q", name)
+	}
+}
+{{end}}
+{{- end}}
+
+{{- define "registration"}}
+{{- if .GenerateRegistry}}
+func init() {
+{{- range .Methods}}
+	{{$.RegistryQualifier}}DefaultRegistry.Register("{{.ToolName}}", ` + "`" + `{{.ToolDescription}}` + "`" + `, reflect.TypeOf({{.StructName}}{}))
 {{- end}}
 }
 {{end}}
+{{- end}}
+
+{{- define "registry" -}}
+// Code generated by google-discovery-mcp. DO NOT EDIT.
+
+package {{.PackageName}}
 
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+{{template "registrytypes"}}
+{{- end}}
+
+{{- define "registrytypes"}}
+// ToolInfo describes a registered tool.
+type ToolInfo struct {
+	Name        string
+	Description string
+	ArgsType    reflect.Type
+}
+
+// Registry collects the tools registered by generated files.
+type Registry struct {
+	mu    sync.Mutex
+	tools map[string]ToolInfo
+}
+
+// DefaultRegistry is populated by the init functions of generated files.
+var DefaultRegistry = &Registry{}
+
+// Register adds a tool. It panics if a tool with the same name is already registered.
+func (r *Registry) Register(name, description string, argsType reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tools == nil {
+		r.tools = make(map[string]ToolInfo)
+	}
+	if _, ok := r.tools[name]; ok {
+		panic(fmt.Sprintf("tool %q registered twice", name))
+	}
+	r.tools[name] = ToolInfo{Name: name, Description: description, ArgsType: argsType}
+}
+
+// Tools returns all registered tools sorted by name.
+func (r *Registry) Tools() []ToolInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tools := make([]ToolInfo, 0, len(r.tools))
+	for _, t := range r.tools {
+		tools = append(tools, t)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+{{- end}}
+
+{{- define "definitions"}}
+{{- if not .SchemaOnly}}
+{{- if not .OmitToolDefinitions}}
 // GeneratedToolDefinitions returns MCP tool definitions for the generated tools.
 // Use this to register tools with your MCP server.
 var GeneratedToolDefinitions = map[string]string{
 {{- range .Methods}}
-	"{{.ToolName}}": ` + "`" + `{{.Description}}` + "`" + `,
+	"{{.ToolName}}": ` + "`" + `{{.ToolDescription}}` + "`" + `,
+{{- end}}
+}
+{{- end}}
+
+// AllTools returns the names of the generated tools, sorted, for iterating them
+// in a stable order. Each call returns a new slice.
+func AllTools() []string {
+	return []string{
+{{- range .ToolNames}}
+		{{printf "%q" .}},
+{{- end}}
+	}
+}
+{{- if .InputSchemaMap}}
+
+// GeneratedInputSchemas holds, per tool, the JSON Schema of its arguments, for
+// servers that register tools from schemas instead of Go types.
+var GeneratedInputSchemas = map[string]map[string]{{.AnyType}}{
+{{- range .Methods}}
+	"{{.ToolName}}": {{.InputSchemaLiteral}},
+{{- end}}
+}
+{{- end}}
+{{- if .GenerateAssertions}}
+
+// Compile-time check that every tool has a generated args type.
+var _ = map[string]{{.AnyType}}{
+{{- range .Methods}}
+	"{{.ToolName}}": {{.StructName}}{},
+{{- end}}
+}
+{{- end}}
+{{- if .HasResponseMetadata}}
+
+// GeneratedToolResponses describes the bodies each tool exchanges. RequestType
+// is the type of the request body and ResponseType the type of the alt=json
+// response body, either empty when the method has none; MediaDownload reports
+// whether the method can instead return the raw media bytes with alt=media.
+var GeneratedToolResponses = map[string]struct {
+	RequestType   string
+	ResponseType  string
+	MediaDownload bool
+}{
+{{- range .Methods}}
+{{- if .HasResponseMetadata}}
+	"{{.ToolName}}": {RequestType: {{printf "%q" .RequestType}}, ResponseType: {{printf "%q" .ResponseType}}, MediaDownload: {{.Method.SupportsMediaDownload}}},
+{{- end}}
+{{- end}}
+}
+{{- end}}
+{{- if .HasRepeatedPathParams}}
+
+// GeneratedRepeatedPathParams lists, per tool, the path parameters that accept
+// several values. They are slices in the args struct but fill a single path segment.
+var GeneratedRepeatedPathParams = map[string][]string{
+{{- range $m := .Methods}}
+{{- with $m.RepeatedPathParams}}
+	"{{$m.ToolName}}": { {{- range $i, $n := .}}{{if $i}}, {{end}}{{printf "%q" $n}}{{end -}} },
+{{- end}}
+{{- end}}
+}
+{{- end}}
+
+// GeneratedToolQuotaCost holds, per tool, the quota cost hint of its method, for
+// reasoning about rate limits. Only documents carrying the quotaCost method
+// extension provide hints; tools without one are absent.
+var GeneratedToolQuotaCost = map[string]int{
+{{- range .Methods}}
+{{- if .Method.QuotaCost}}
+	"{{.ToolName}}": {{.Method.QuotaCost}},
+{{- end}}
 {{- end}}
 }
+{{- if .GenerateCheckRequired}}
+
+// GeneratedRequiredParams lists, per tool, the argument keys that must be set.
+var GeneratedRequiredParams = map[string][]string{
+{{- range $m := .Methods}}
+{{- with $m.RequiredArgs}}
+	"{{$m.ToolName}}": { {{- range $i, $n := .}}{{if $i}}, {{end}}{{printf "%q" $n}}{{end -}} },
+{{- end}}
+{{- end}}
+}
+
+// CheckRequired returns an error naming the required arguments of tool that are
+// missing or null in args, for validating raw tool arguments before binding them
+// to the args struct. Unknown tools and tools without required arguments pass.
+func CheckRequired(tool string, args map[string]{{.AnyType}}) error {
+	var missing []string
+	for _, name := range GeneratedRequiredParams[tool] {
+		if v, ok := args[name]; !ok || v == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s: missing required arguments: %s", tool, strings.Join(missing, ", "))
+	}
+	return nil
+}
+{{- end}}
+{{- end}}
+{{- if .GenerateEnumValues}}
+
+// GeneratedEnumValues lists the allowed values of every enum field, keyed by
+// "StructName.FieldName", for validating input at runtime.
+var GeneratedEnumValues = map[string][]string{
+{{- range .EnumFields}}
+	"{{.Key}}": { {{- range $i, $v := .Values}}{{if $i}}, {{end}}{{printf "%q" $v}}{{end -}} },
+{{- end}}
+}
+{{- end}}
+{{- end}}
 `))
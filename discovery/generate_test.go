@@ -1,7 +1,18 @@
 package discovery
 
 import (
+	"encoding/json"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -49,7 +60,7 @@ func TestScalarGoType(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := scalarGoType(tt.typ, tt.format, tt.optional)
+			got := scalarGoType(tt.typ, tt.format, tt.optional, nil)
 			if got != tt.want {
 				t.Errorf("scalarGoType(%q, %q, %v) = %q, want %q",
 					tt.typ, tt.format, tt.optional, got, tt.want)
@@ -98,7 +109,7 @@ func TestParamGoType(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := paramGoType(tt.param)
+			got := paramGoType(tt.param, tt.param.Required, nil)
 			if got != tt.want {
 				t.Errorf("paramGoType() = %q, want %q", got, tt.want)
 			}
@@ -119,6 +130,16 @@ func TestPropertyInfoGoType(t *testing.T) {
 			ID:   "StringWrapper",
 			Type: "string",
 		},
+		"Typeless": {
+			ID: "Typeless",
+			Properties: map[string]*Schema{
+				"name": {Type: "string"},
+			},
+		},
+		"TypelessMap": {
+			ID:                   "TypelessMap",
+			AdditionalProperties: &Schema{Type: "string"},
+		},
 	}
 
 	tests := []struct {
@@ -175,6 +196,18 @@ func TestPropertyInfoGoType(t *testing.T) {
 			required: false,
 			want:     "string",
 		},
+		{
+			name:     "ref to typeless schema with properties",
+			property: &Schema{Ref: "Typeless"},
+			required: false,
+			want:     "*Typeless",
+		},
+		{
+			name:     "ref to typeless schema with additionalProperties",
+			property: &Schema{Ref: "TypelessMap"},
+			required: false,
+			want:     "*TypelessMap",
+		},
 		{
 			name:     "map with string values",
 			property: &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}},
@@ -270,7 +303,7 @@ func TestCollectSchemas(t *testing.T) {
 		},
 	}
 
-	schemas := collectSchemas(methods, allSchemas)
+	schemas := collectSchemas(methods, allSchemas, nil)
 
 	// Should collect Video and all its dependencies
 	schemaNames := make(map[string]bool)
@@ -292,6 +325,106 @@ func TestCollectSchemas(t *testing.T) {
 	}
 }
 
+func TestCollectRootSchemas(t *testing.T) {
+	allSchemas := map[string]*Schema{
+		"Video":            {ID: "Video", Type: "object", Properties: map[string]*Schema{"snippet": {Ref: "VideoSnippet"}}},
+		"VideoSnippet":     {ID: "VideoSnippet", Type: "object", Properties: map[string]*Schema{"thumbnails": {Ref: "ThumbnailDetails"}}},
+		"ThumbnailDetails": {ID: "ThumbnailDetails", Type: "object", Properties: map[string]*Schema{"default": {Ref: "Thumbnail"}}},
+		"Thumbnail":        {ID: "Thumbnail", Type: "object", Properties: map[string]*Schema{"url": {Type: "string"}}},
+		"VideoListResponse": {ID: "VideoListResponse", Type: "object", Properties: map[string]*Schema{
+			"items": {Type: "array", Items: &Schema{Ref: "Video"}},
+		}},
+	}
+
+	schemas, err := collectRootSchemas([]string{"Video"}, allSchemas, nil)
+	if err != nil {
+		t.Fatalf("collectRootSchemas failed: %v", err)
+	}
+	var names []string
+	for _, s := range schemas {
+		names = append(names, s.Name)
+	}
+	want := []string{"Thumbnail", "ThumbnailDetails", "Video", "VideoSnippet"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("collected %v, want %v", names, want)
+	}
+
+	if _, err := collectRootSchemas([]string{"Channel"}, allSchemas, nil); err == nil || !strings.Contains(err.Error(), "Channel") {
+		t.Errorf("expected an error naming the unknown root schema, got %v", err)
+	}
+}
+
+func TestGenerateMCPToolsRootSchemas(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{RootSchemas: []string{"Video"}})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{"type Video struct", "type VideoSnippet struct"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	for _, unwanted := range []string{"type VideoListResponse struct", "APIVideosListArgs", "GeneratedToolDefinitions"} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("RootSchemas should ignore methods, but generated %q", unwanted)
+		}
+	}
+}
+
+func TestGenerateMCPToolsTopLevelArraySchema(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Schemas: map[string]*Schema{
+			"VideoList": {ID: "VideoList", Type: "array", Description: "A list of videos.", Items: &Schema{Ref: "Video"}},
+			"TagList":   {ID: "TagList", Type: "array", Items: &Schema{Type: "string"}},
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{
+				"tags": {Ref: "TagList"},
+			}},
+			"Playlist": {ID: "Playlist", Type: "object", Properties: map[string]*Schema{
+				"videos": {Ref: "VideoList"},
+			}},
+		},
+		Resources: map[string]*Resource{
+			"playlists": {Methods: map[string]*Method{
+				"get": {ID: "test.playlists.get", HTTPMethod: "GET", Path: "playlists", Response: &SchemaRef{Ref: "Playlist"}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		"// VideoList - A list of videos.\ntype VideoList []*Video\n",
+		"type TagList []string\n",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "type VideoList struct") {
+		t.Errorf("a top-level array schema should not become an empty struct\nGenerated code:\n%s", code)
+	}
+	// Video is only reachable through VideoList's items.
+	if !strings.Contains(code, "type Video struct") {
+		t.Errorf("schemas referenced by array items should be collected\nGenerated code:\n%s", code)
+	}
+	if !containsFieldType(code, "Videos", "VideoList") || !containsFieldType(code, "Tags", "TagList") {
+		t.Errorf("references to slice types should not be pointers\nGenerated code:\n%s", code)
+	}
+	runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go":  "package main\n\nfunc main() { _ = Playlist{Videos: VideoList{{Tags: TagList{\"a\"}}}} }\n",
+	})
+}
+
 func TestCollectSchemasWithArrays(t *testing.T) {
 	allSchemas := map[string]*Schema{
 		"VideoListResponse": {
@@ -318,7 +451,7 @@ func TestCollectSchemasWithArrays(t *testing.T) {
 		},
 	}
 
-	schemas := collectSchemas(methods, allSchemas)
+	schemas := collectSchemas(methods, allSchemas, nil)
 
 	schemaNames := make(map[string]bool)
 	for _, s := range schemas {
@@ -379,6 +512,9 @@ func TestExportedName(t *testing.T) {
 		{"madeForKids", "MadeForKids"},
 		{"snake_case", "SnakeCase"},
 		{"kebab-case", "KebabCase"},
+		{"3d", "X3d"},
+		{"360Video", "X360Video"},
+		{"3d_model", "X3dModel"},
 	}
 
 	for _, tt := range tests {
@@ -557,3 +693,3549 @@ func TestParamInfoJSONTag(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateMCPToolsSplitReadWrite(t *testing.T) {
+	newDoc := func(props map[string]*Schema) *Document {
+		return &Document{
+			Name:    "test",
+			Version: "v1",
+			Title:   "Test API",
+			Schemas: map[string]*Schema{
+				"Video": {ID: "Video", Type: "object", Properties: props},
+			},
+			Resources: map[string]*Resource{
+				"videos": {
+					Methods: map[string]*Method{
+						"insert": {
+							ID:       "videos.insert",
+							Request:  &SchemaRef{Ref: "Video"},
+							Response: &SchemaRef{Ref: "Video"},
+						},
+					},
+				},
+			},
+		}
+	}
+	opts := GenerateOptions{GenerateSchema: true, SplitReadWrite: true}
+
+	t.Run("no readOnly fields", func(t *testing.T) {
+		code, err := GenerateMCPTools(newDoc(map[string]*Schema{
+			"title": {Type: "string"},
+		}), opts)
+		if err != nil {
+			t.Fatalf("GenerateMCPTools failed: %v", err)
+		}
+		if strings.Count(code, "type Video") != 1 {
+			t.Errorf("expected a single Video struct\nGenerated code:\n%s", code)
+		}
+		if strings.Contains(code, "type VideoRequest struct") {
+			t.Error("VideoRequest should not be generated when Video has no readOnly fields")
+		}
+	})
+
+	t.Run("with readOnly fields", func(t *testing.T) {
+		code, err := GenerateMCPTools(newDoc(map[string]*Schema{
+			"title":     {Type: "string"},
+			"viewCount": {Type: "string", ReadOnly: true},
+		}), opts)
+		if err != nil {
+			t.Fatalf("GenerateMCPTools failed: %v", err)
+		}
+		if !strings.Contains(code, "type Video struct") || !strings.Contains(code, "type VideoRequest struct") {
+			t.Fatalf("expected both Video and VideoRequest\nGenerated code:\n%s", code)
+		}
+		req := code[strings.Index(code, "type VideoRequest struct"):]
+		req = req[:strings.Index(req, "}")]
+		if strings.Contains(req, "ViewCount") {
+			t.Errorf("VideoRequest should not contain readOnly field ViewCount\n%s", req)
+		}
+	})
+}
+
+func TestCollectRequestSchemasNested(t *testing.T) {
+	allSchemas := map[string]*Schema{
+		"Video": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"snippet": {Ref: "VideoSnippet"},
+				"status":  {Ref: "VideoStatus"},
+			},
+		},
+		"VideoSnippet": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"title":       {Type: "string"},
+				"publishedAt": {Type: "string", ReadOnly: true},
+			},
+		},
+		"VideoStatus": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"privacyStatus": {Type: "string"},
+			},
+		},
+	}
+	methods := []*MethodInfo{{Method: &Method{Request: &SchemaRef{Ref: "Video"}}}}
+
+	variants := collectRequestSchemas(methods, allSchemas, nil)
+	var names []string
+	for _, v := range variants {
+		names = append(names, v.StructName())
+	}
+	if strings.Join(names, ",") != "VideoRequest,VideoSnippetRequest" {
+		t.Fatalf("request variants = %v, want [VideoRequest VideoSnippetRequest]", names)
+	}
+
+	for _, p := range variants[0].SortedProperties() {
+		switch p.Name {
+		case "snippet":
+			if got := p.GoType(); got != "*VideoSnippetRequest" {
+				t.Errorf("snippet type = %q, want *VideoSnippetRequest", got)
+			}
+		case "status":
+			if got := p.GoType(); got != "*VideoStatus" {
+				t.Errorf("status type = %q, want *VideoStatus", got)
+			}
+		}
+	}
+}
+
+func TestGenerateMCPToolsExamples(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Title:   "Test API",
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						ID:          "videos.list",
+						Description: "List videos",
+						Parameters: map[string]*Parameter{
+							"part":       {Type: "string", Required: true, Repeated: true, Enum: []string{"snippet", "status"}},
+							"maxResults": {Type: "integer", Format: "uint32", Required: true, Minimum: "1"},
+							"pageToken":  {Type: "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateExamples: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"// Example:",
+		"//\tAPIVideosListArgs{",
+		`//		Part: []string{"snippet"},`,
+		"//\t\tMaxResults: 1,",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code should contain %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "PageToken:") {
+		t.Error("example should only populate required parameters")
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "// Example:") {
+		t.Error("examples should not be generated unless GenerateExamples is set")
+	}
+}
+
+func TestGenerateMCPToolsAPIInfo(t *testing.T) {
+	doc := &Document{
+		Name:              "youtube",
+		Version:           "v3",
+		Title:             "YouTube Data API v3",
+		RootURL:           "https://youtube.googleapis.com/",
+		ServicePath:       "youtube/v3/",
+		DocumentationLink: "https://developers.google.com/youtube/",
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"var GeneratedAPIInfo = struct {",
+		`Name:        "youtube",`,
+		`Version:     "v3",`,
+		`Title:       "YouTube Data API v3",`,
+		`RootURL:     "https://youtube.googleapis.com/",`,
+		`ServicePath: "youtube/v3/",`,
+		`DocsLink:    "https://developers.google.com/youtube/",`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code should contain %q\nGenerated code:\n%s", want, code)
+		}
+	}
+}
+
+func TestGenerateMCPToolsCommonParams(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Title:   "Test API",
+		Parameters: map[string]*Parameter{
+			"fields":    {Type: "string", Location: "query", Description: "Selector specifying a subset of fields"},
+			"quotaUser": {Type: "string", Location: "query", Description: "Quota user"},
+			"key":       {Type: "string", Location: "query", Description: "API key"},
+		},
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						ID: "videos.list",
+						Parameters: map[string]*Parameter{
+							"part": {Type: "string", Required: true},
+							"key":  {Type: "string", Required: true, Description: "Method-specific key"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{IncludeCommonParams: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{`json:"fields,omitempty"`, `json:"quotaUser,omitempty"`, `json:"key" jsonschema:"Method-specific key"`} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code should contain %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	if strings.Count(code, `json:"key`) != 1 {
+		t.Errorf("method parameter should override the common parameter\nGenerated code:\n%s", code)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, `json:"quotaUser`) {
+		t.Error("common parameters should not be included by default")
+	}
+}
+
+func TestGenerateMCPToolsInputSchema(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Title:   "Test API",
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						ID: "videos.list",
+						Parameters: map[string]*Parameter{
+							"part":       {Type: "string", Required: true, Repeated: true, Description: "Parts to include"},
+							"chart":      {Type: "string", Enum: []string{"chartUnspecified", "mostPopular"}},
+							"maxResults": {Type: "integer"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	m := &MethodInfo{FullName: "videos.list", Method: doc.Resources["videos"].Methods["list"]}
+	schema := m.InputSchema()
+	if schema["type"] != "object" {
+		t.Errorf("schema type = %v, want object", schema["type"])
+	}
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		t.Fatalf("properties has type %T, want map[string]any", schema["properties"])
+	}
+	part, ok := props["part"].(map[string]any)
+	if !ok {
+		t.Fatalf("properties.part missing: %v", props)
+	}
+	if part["type"] != "array" || part["description"] != "Parts to include" {
+		t.Errorf("properties.part = %v", part)
+	}
+	if req, _ := schema["required"].([]string); len(req) != 1 || req[0] != "part" {
+		t.Errorf("required = %v, want [part]", schema["required"])
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateInputSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "func (APIVideosListArgs) InputSchema() map[string]any {") {
+		t.Errorf("InputSchema method should be generated\nGenerated code:\n%s", code)
+	}
+	// go fmt aligns map values, so match key and value separately.
+	for _, kv := range [][2]string{
+		{`"type":`, `"object",`},
+		{`"enum":`, `[]string{"chartUnspecified", "mostPopular"},`},
+		{`"required":`, `[]string{"part"},`},
+	} {
+		if !containsFieldType(code, regexp.QuoteMeta(kv[0]), kv[1]) {
+			t.Errorf("generated code should contain %s %s\nGenerated code:\n%s", kv[0], kv[1], code)
+		}
+	}
+}
+
+func TestFilterMethodsRegex(t *testing.T) {
+	all := []string{"playlists.list", "videos.delete", "videos.insert", "videos.list", "videos.rate"}
+	got, err := filterMethods(GenerateOptions{MethodsRegex: `^videos\.(list|insert)$`}, all)
+	if err != nil {
+		t.Fatalf("filterMethods failed: %v", err)
+	}
+	if want := "videos.insert,videos.list"; strings.Join(got, ",") != want {
+		t.Errorf("filterMethods = %v, want %s", got, want)
+	}
+
+	got, err = filterMethods(GenerateOptions{Methods: []string{"*.list"}, MethodsRegex: `^videos\.`}, all)
+	if err != nil {
+		t.Fatalf("filterMethods failed: %v", err)
+	}
+	if want := "videos.list"; strings.Join(got, ",") != want {
+		t.Errorf("Methods and MethodsRegex should intersect, got %v, want %s", got, want)
+	}
+
+	if _, err := filterMethods(GenerateOptions{MethodsRegex: `videos.(list`}, all); err == nil || !strings.Contains(err.Error(), "invalid methods regex") {
+		t.Errorf("invalid regex = %v, want invalid methods regex error", err)
+	}
+	if _, err := filterMethods(GenerateOptions{MethodsRegex: `^comments\.`}, all); err == nil || !strings.Contains(err.Error(), "no methods match regex") {
+		t.Errorf("regex without matches = %v, want no methods match error", err)
+	}
+}
+
+func TestGenerateFiles(t *testing.T) {
+	doc := &Document{
+		Name:    "youtube",
+		Version: "v3",
+		Title:   "YouTube Data API v3",
+		RootURL: "https://youtube.googleapis.com/",
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {ID: "youtube.videos.list", Description: "List videos"},
+				},
+			},
+		},
+	}
+
+	files, err := GenerateFiles(doc, GenerateOptions{PackageName: "youtube"})
+	if err != nil {
+		t.Fatalf("GenerateFiles failed: %v", err)
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "doc.go,tools.go" {
+		t.Fatalf("file names = %v, want [doc.go tools.go]", names)
+	}
+
+	docFile := files["doc.go"]
+	if !strings.Contains(docFile, "// Package youtube contains") || !strings.Contains(docFile, "var GeneratedAPIInfo") {
+		t.Errorf("doc.go should hold the package comment and GeneratedAPIInfo\n%s", docFile)
+	}
+	tools := files["tools.go"]
+	if !strings.Contains(tools, "package youtube") || !strings.Contains(tools, "type APIVideosListArgs struct") {
+		t.Errorf("tools.go should hold the args structs\n%s", tools)
+	}
+	if strings.Contains(tools, "GeneratedAPIInfo") {
+		t.Error("GeneratedAPIInfo should only be emitted once, in doc.go")
+	}
+}
+
+func TestGenerateFilesSeparateSchemaFile(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := GenerateOptions{PackageName: "main", GenerateSchema: true, GenerateEnums: true, SeparateSchemaFile: true}
+	files, err := GenerateFiles(doc, opts)
+	if err != nil {
+		t.Fatalf("GenerateFiles failed: %v", err)
+	}
+	schemas, tools := files["schemas.go"], files["tools.go"]
+	for _, want := range []string{"type Video struct", "type VideoListResponse struct", "type Chart string"} {
+		if !strings.Contains(schemas, want) {
+			t.Errorf("schemas.go missing %q\n%s", want, schemas)
+		}
+		if strings.Contains(tools, want) {
+			t.Errorf("tools.go should leave %q to schemas.go", want)
+		}
+	}
+	if !strings.Contains(tools, "type APIVideosListArgs struct") {
+		t.Errorf("tools.go should hold the args structs\n%s", tools)
+	}
+	if strings.Contains(schemas, "Args struct") {
+		t.Errorf("schemas.go should not hold args structs\n%s", schemas)
+	}
+	header := "// Code generated by google-discovery-mcp. DO NOT EDIT.\n// Source: youtube v3\n// API: YouTube Data API v3\n\npackage main\n"
+	for name, code := range map[string]string{"schemas.go": schemas, "tools.go": tools} {
+		if !strings.HasPrefix(code, header) {
+			t.Errorf("%s should start with the shared header and package clause\n%s", name, code)
+		}
+	}
+
+	runGenerated(t, map[string]string{
+		"schemas.go": schemas,
+		"tools.go":   tools,
+		"main.go":    "package main\n\nfunc main() { _ = APIVideosListArgs{Chart: ChartMostPopular}; _ = Video{} }\n",
+	})
+
+	files, err = GenerateFiles(doc, GenerateOptions{SeparateSchemaFile: true})
+	if err != nil {
+		t.Fatalf("GenerateFiles failed: %v", err)
+	}
+	if _, ok := files["schemas.go"]; ok {
+		t.Error("schemas.go should be omitted when there are no schema or enum types")
+	}
+}
+
+func TestGenerateFilesSubpackage(t *testing.T) {
+	doc := &Document{
+		Name:    "youtube",
+		Version: "v3",
+		Schemas: map[string]*Schema{
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{"id": {Type: "string"}}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"list": {ID: "youtube.videos.list", Response: &SchemaRef{Ref: "Video"}},
+			}},
+		},
+	}
+	opts := GenerateOptions{
+		PackageName:      "youtube",
+		GenerateSchema:   true,
+		GenerateRegistry: true,
+		Subpackage:       "generated",
+		SubpackageImport: "gentest/youtube/generated",
+		BuildTags:        []string{"gen"},
+	}
+
+	files, err := GenerateFiles(doc, opts)
+	if err != nil {
+		t.Fatalf("GenerateFiles failed: %v", err)
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if got, want := strings.Join(names, ","), "generated.go,generated/doc.go,generated/registry.go,generated/tools.go"; got != want {
+		t.Fatalf("file names = %s, want %s", got, want)
+	}
+	for _, name := range names[1:] {
+		if !strings.Contains(files[name], "\npackage generated\n") {
+			t.Errorf("%s should declare package generated\n%s", name, files[name])
+		}
+	}
+	reexport := files["generated.go"]
+	for _, want := range []string{
+		"//go:build gen",
+		"\npackage youtube\n",
+		`import "gentest/youtube/generated"`,
+		"APIVideosListArgs = generated.APIVideosListArgs",
+		"GeneratedToolDefinitions = generated.GeneratedToolDefinitions",
+		"DefaultRegistry          = generated.DefaultRegistry",
+	} {
+		if !strings.Contains(reexport, want) {
+			t.Errorf("re-export file missing %q\n%s", want, reexport)
+		}
+	}
+
+	all := map[string]string{
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"gentest/youtube"
+)
+
+func main() {
+	var args youtube.APIVideosListArgs
+	var video youtube.Video
+	fmt.Println(args, video, len(youtube.GeneratedToolDefinitions), len(youtube.DefaultRegistry.Tools()))
+}
+`,
+	}
+	for name, content := range files {
+		all["youtube/"+name] = content
+	}
+	if out := runGo(t, all, "run", "-tags", "gen", "."); !strings.Contains(out, " 1 1") {
+		t.Errorf("re-exported package should expose the tool, got %q", out)
+	}
+
+	opts.SubpackageImport = ""
+	if _, err := GenerateFiles(doc, opts); err == nil || !strings.Contains(err.Error(), "SubpackageImport") {
+		t.Errorf("Subpackage without SubpackageImport should fail, got %v", err)
+	}
+	opts.SubpackageImport = "gentest/youtube/generated"
+	opts.Subpackage = "../generated"
+	if _, err := GenerateFiles(doc, opts); err == nil {
+		t.Error("a Subpackage outside the output directory should be rejected")
+	}
+}
+
+func TestGenerateMCPToolsPreserveOrder(t *testing.T) {
+	doc, err := Parse([]byte(`{
+		"name": "test",
+		"schemas": {
+			"Video": {
+				"type": "object",
+				"properties": {
+					"snippet": {"type": "string"},
+					"id": {"type": "string"},
+					"etag": {"type": "string"}
+				}
+			}
+		},
+		"resources": {"videos": {"methods": {"list": {
+			"id": "videos.list",
+			"parameters": {
+				"pageToken": {"type": "string"},
+				"part": {"type": "string", "required": true},
+				"maxResults": {"type": "integer"}
+			},
+			"response": {"$ref": "Video"}
+		}}}}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	fieldOrder := func(code string, fields ...string) bool {
+		last := -1
+		for _, f := range fields {
+			i := strings.Index(code, "\t"+f+" ")
+			if i < last {
+				return false
+			}
+			last = i
+		}
+		return true
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, PreserveOrder: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !fieldOrder(code, "PageToken", "Part", "MaxResults") {
+		t.Errorf("parameters should follow document order\nGenerated code:\n%s", code)
+	}
+	if !fieldOrder(code, "Snippet", "ID", "Etag") {
+		t.Errorf("properties should follow document order\nGenerated code:\n%s", code)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !fieldOrder(code, "Part", "MaxResults", "PageToken") || !fieldOrder(code, "Etag", "ID", "Snippet") {
+		t.Errorf("default order should be required first, then alphabetical\nGenerated code:\n%s", code)
+	}
+}
+
+func TestPropertyInfoConstraints(t *testing.T) {
+	doc, err := Parse([]byte(`{
+		"name": "test",
+		"schemas": {
+			"Playlist": {
+				"type": "object",
+				"properties": {
+					"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": "30"},
+					"title": {"type": "string", "description": "Title", "maxLength": 150},
+					"id": {"type": "string"}
+				}
+			}
+		},
+		"resources": {"playlists": {"methods": {"get": {"id": "playlists.get", "response": {"$ref": "Playlist"}}}}}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		`json:"tags,omitempty" jsonschema:"minItems=1,maxItems=30"`,
+		`json:"title,omitempty" jsonschema:"Title,maxLength=150"`,
+		`json:"id,omitempty" jsonschema:""`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code should contain %q\nGenerated code:\n%s", want, code)
+		}
+	}
+}
+
+func TestGenerateMCPToolsBuildTags(t *testing.T) {
+	doc := &Document{Name: "test", Version: "v1", Title: "Test API"}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{BuildTags: []string{"integration", "!windows"}})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	want := "//go:build integration && !windows\n// +build integration,!windows\n\n// Code generated"
+	if !strings.HasPrefix(code, want) {
+		t.Errorf("generated code should start with build constraints\nGenerated code:\n%s", code)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.HasPrefix(code, "// Code generated") {
+		t.Errorf("without build tags the header should come first\nGenerated code:\n%s", code)
+	}
+
+	if _, err := GenerateMCPTools(doc, GenerateOptions{BuildTags: []string{"a &&"}}); err == nil {
+		t.Error("expected an error for an invalid build tag")
+	}
+}
+
+func TestSelectMethods(t *testing.T) {
+	all := []string{"channels.list", "playlists.insert", "playlists.list", "videos.insert", "videos.list"}
+
+	got, err := selectMethods([]string{"videos.list", "playlists.*", "*.list"}, all)
+	if err != nil {
+		t.Fatalf("selectMethods failed: %v", err)
+	}
+	want := "videos.list,playlists.insert,playlists.list,channels.list"
+	if strings.Join(got, ",") != want {
+		t.Errorf("selectMethods = %v, want %s", got, want)
+	}
+
+	if _, err := selectMethods([]string{"videos.rate"}, all); err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Errorf("expected method not found error, got %v", err)
+	}
+	if _, err := selectMethods([]string{"comments.*"}, all); err == nil || !strings.Contains(err.Error(), "no methods match") {
+		t.Errorf("expected no match error, got %v", err)
+	}
+}
+
+func TestGenerateMCPToolsHandlers(t *testing.T) {
+	doc := &Document{
+		Name:    "youtube",
+		Version: "v3",
+		Title:   "YouTube Data API v3",
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						ID:          "youtube.videos.list",
+						Description: "List videos",
+						Parameters: map[string]*Parameter{
+							"part":       {Type: "string", Required: true, Repeated: true},
+							"maxResults": {Type: "integer", Format: "uint32"},
+							"mine":       {Type: "boolean"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{
+		PackageName:      "main",
+		GenerateHandlers: true,
+		MCPImportPath:    fakeMCPImportPath,
+	})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "func handleVideosList(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error)") {
+		t.Fatalf("handler stub should be generated\nGenerated code:\n%s", code)
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gentest/mcp"
+)
+
+func main() {
+	var req mcp.CallToolRequest
+	_ = json.Unmarshal([]byte(` + "`" + `{"part": ["snippet", "status"], "maxResults": 5, "mine": false}` + "`" + `), &req.Params.Arguments)
+
+	var args APIVideosListArgs
+	if err := bindArguments(req, &args); err != nil {
+		panic(err)
+	}
+	fmt.Printf("%v %d %v\n", args.Part, args.MaxResults, *args.Mine)
+
+	RegisterTools(func(name, description string, handler ToolHandler) {
+		res, _ := handler(context.Background(), req)
+		fmt.Printf("%s %q %s\n", name, description, res.Text)
+	})
+}
+`,
+	})
+
+	want := "[snippet status] 5 false\nyoutube_videos_list \"List videos\" youtube_videos_list is not implemented\n"
+	if out != want {
+		t.Errorf("generated handler output = %q, want %q", out, want)
+	}
+}
+
+func TestGenerateMCPToolsOmitSchemaTags(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Title:   "Test API",
+		Schemas: map[string]*Schema{
+			"Video": {Type: "object", Properties: map[string]*Schema{"id": {Type: "string", Description: "Video ID"}}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						ID:         "videos.list",
+						Parameters: map[string]*Parameter{"part": {Type: "string", Required: true, Description: "Parts"}},
+						Response:   &SchemaRef{Ref: "Video"},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, OmitSchemaTags: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "jsonschema:") {
+		t.Errorf("jsonschema tags should be omitted\nGenerated code:\n%s", code)
+	}
+	for _, want := range []string{"`json:\"id,omitempty\"`", "`json:\"part\"`"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code should contain %s\nGenerated code:\n%s", want, code)
+		}
+	}
+}
+
+func TestMethodInfoToolNameSeparator(t *testing.T) {
+	tests := []struct {
+		name      string
+		fullName  string
+		separator string
+		want      string
+	}{
+		{"default separator", "liveChat.messages.list", "", "youtube_liveChat_messages_list"},
+		{"dash separator", "liveChat.messages.list", "-", "youtube_liveChat-messages-list"},
+		{"double underscore", "liveChat.messages.list", "__", "youtube_liveChat__messages__list"},
+		{"invalid separator is sanitized", "liveChat.messages.list", "/", "youtube_liveChat_messages_list"},
+		{"invalid method characters are sanitized", "media.upload:resumable", "_", "youtube_media_upload_resumable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MethodInfo{FullName: tt.fullName, Prefix: "youtube_", Separator: tt.separator}
+			if got := m.ToolName(); got != tt.want {
+				t.Errorf("ToolName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateMCPToolsResourceSeparator(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Resources: map[string]*Resource{
+			"liveChat": {
+				Resources: map[string]*Resource{
+					"messages": {Methods: map[string]*Method{"list": {ID: "youtube.liveChat.messages.list"}}},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{ResourceSeparator: "-"})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, `"youtube_liveChat-messages-list"`) {
+		t.Errorf("tool name should use the custom separator\nGenerated code:\n%s", code)
+	}
+
+	// "a.b_c" and "a.b.c" collide once dots become underscores.
+	doc.Resources["a"] = &Resource{
+		Methods:   map[string]*Method{"b_c": {}},
+		Resources: map[string]*Resource{"b": {Methods: map[string]*Method{"c": {}}}},
+	}
+	if _, err := GenerateMCPTools(doc, GenerateOptions{}); err == nil || !strings.Contains(err.Error(), "youtube_a_b_c") {
+		t.Errorf("expected duplicate tool name error, got %v", err)
+	}
+}
+
+func TestGenerateMCPToolsLeadingDigitNames(t *testing.T) {
+	doc := &Document{
+		Name: "test",
+		Schemas: map[string]*Schema{
+			"Asset": {Type: "object", Properties: map[string]*Schema{"3dModel": {Type: "string"}}},
+		},
+		Resources: map[string]*Resource{
+			"assets": {
+				Methods: map[string]*Method{
+					"get": {
+						Parameters: map[string]*Parameter{"360Video": {Type: "boolean"}},
+						Response:   &SchemaRef{Ref: "Asset"},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed (invalid identifiers?): %v", err)
+	}
+	if !containsFieldType(code, "X3dModel", "string") || !strings.Contains(code, `json:"3dModel,omitempty"`) {
+		t.Errorf("3dModel should become X3dModel with the original json name\nGenerated code:\n%s", code)
+	}
+	if !containsFieldType(code, "X360Video", "*bool") || !strings.Contains(code, `json:"360Video,omitempty"`) {
+		t.Errorf("360Video should become X360Video with the original json name\nGenerated code:\n%s", code)
+	}
+}
+
+func TestOptionalAsPointer(t *testing.T) {
+	opts := &GenerateOptions{OptionalAsPointer: true}
+
+	params := []struct {
+		name  string
+		param *Parameter
+		want  string
+	}{
+		{"optional string", &Parameter{Type: "string"}, "*string"},
+		{"optional integer", &Parameter{Type: "integer", Format: "int64"}, "*int64"},
+		{"optional number", &Parameter{Type: "number"}, "*float64"},
+		{"optional boolean", &Parameter{Type: "boolean"}, "*bool"},
+		{"required string", &Parameter{Type: "string", Required: true}, "string"},
+		{"repeated string", &Parameter{Type: "string", Repeated: true}, "[]string"},
+	}
+	for _, tt := range params {
+		t.Run("param "+tt.name, func(t *testing.T) {
+			p := &ParamInfo{Name: "x", Param: tt.param, Options: opts}
+			if got := p.GoType(); got != tt.want {
+				t.Errorf("GoType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	props := []struct {
+		name     string
+		property *Schema
+		required bool
+		want     string
+	}{
+		{"optional string", &Schema{Type: "string"}, false, "*string"},
+		{"required string", &Schema{Type: "string"}, true, "string"},
+		{"optional any", &Schema{Type: "any"}, false, "any"},
+		{"array elements stay values", &Schema{Type: "array", Items: &Schema{Type: "string"}}, false, "[]string"},
+		{"object ref unchanged", &Schema{Ref: "Video"}, false, "*Video"},
+	}
+	for _, tt := range props {
+		t.Run("property "+tt.name, func(t *testing.T) {
+			p := &PropertyInfo{Name: "x", Property: tt.property, Required: tt.required, Options: opts}
+			if got := p.GoType(); got != tt.want {
+				t.Errorf("GoType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Default behavior is unchanged.
+	p := &ParamInfo{Name: "x", Param: &Parameter{Type: "string"}}
+	if got := p.GoType(); got != "string" {
+		t.Errorf("without OptionalAsPointer, optional string GoType() = %q, want string", got)
+	}
+}
+
+func TestGenerateMCPToolsRegistry(t *testing.T) {
+	newDoc := func(name, resource string) *Document {
+		return &Document{
+			Name:    name,
+			Version: "v1",
+			Resources: map[string]*Resource{
+				resource: {
+					Methods: map[string]*Method{
+						"list": {ID: name + "." + resource + ".list", Description: "List " + resource},
+						"get": {
+							ID:          name + "." + resource + ".get",
+							Description: "Get " + resource,
+							Parameters:  map[string]*Parameter{"id": {Type: "string", Required: true}},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	registry, err := GenerateRegistryFile("tools")
+	if err != nil {
+		t.Fatalf("GenerateRegistryFile failed: %v", err)
+	}
+
+	files := map[string]string{"tools/registry.go": registry}
+	for _, api := range []struct{ name, resource string }{{"youtube", "videos"}, {"drive", "files"}} {
+		code, err := GenerateMCPTools(newDoc(api.name, api.resource), GenerateOptions{
+			PackageName:        api.name,
+			GenerateRegistry:   true,
+			RegistryImportPath: "gentest/tools",
+		})
+		if err != nil {
+			t.Fatalf("GenerateMCPTools(%s) failed: %v", api.name, err)
+		}
+		files[api.name+"/tools.go"] = code
+	}
+	files["main.go"] = `package main
+
+import (
+	"fmt"
+
+	"gentest/tools"
+	_ "gentest/drive"
+	_ "gentest/youtube"
+)
+
+func main() {
+	for _, tool := range tools.DefaultRegistry.Tools() {
+		fmt.Printf("%s %q %s\n", tool.Name, tool.Description, tool.ArgsType)
+	}
+}
+`
+
+	out := runGenerated(t, files)
+	want := `drive_files_get "Get files" drive.APIFilesGetArgs
+drive_files_list "List files" drive.APIFilesListArgs
+youtube_videos_get "Get videos" youtube.APIVideosGetArgs
+youtube_videos_list "List videos" youtube.APIVideosListArgs
+`
+	if out != want {
+		t.Errorf("registry contents = %q, want %q", out, want)
+	}
+
+	// Without RegistryImportPath the package registers into its own DefaultRegistry,
+	// which single-file output defines itself.
+	code, err := GenerateMCPTools(newDoc("youtube", "videos"), GenerateOptions{PackageName: "main", GenerateRegistry: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "\tDefaultRegistry.Register(\"youtube_videos_list\"") {
+		t.Errorf("local registration missing\nGenerated code:\n%s", code)
+	}
+	out = runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	for _, tool := range DefaultRegistry.Tools() {
+		fmt.Println(tool.Name)
+	}
+}
+`,
+	})
+	if want := "youtube_videos_get\nyoutube_videos_list\n"; out != want {
+		t.Errorf("single-file registry contents = %q, want %q", out, want)
+	}
+}
+
+func TestValidateToolNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []*MethodInfo
+		wantErr string
+	}{
+		{
+			name: "valid",
+			methods: []*MethodInfo{
+				{FullName: "videos.list", Prefix: "yt_"},
+				{FullName: "videos.insert", Prefix: "yt_"},
+			},
+		},
+		{
+			name: "duplicate",
+			methods: []*MethodInfo{
+				{FullName: "videos.list", Prefix: "yt_"},
+				{FullName: "videos_list", Prefix: "yt_"},
+			},
+			wantErr: `methods videos.list and videos_list both map to tool name "yt_videos_list"`,
+		},
+		{
+			name:    "illegal character in prefix",
+			methods: []*MethodInfo{{FullName: "videos.list", Prefix: "yt."}},
+			wantErr: `tool name "yt.videos_list" for method videos.list contains characters outside [a-zA-Z0-9_-]`,
+		},
+		{
+			name:    "empty",
+			methods: []*MethodInfo{{FullName: ""}},
+			wantErr: "empty tool name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateToolNames(tt.methods)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateToolNames() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateToolNames() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	doc := &Document{
+		Name:      "youtube",
+		Resources: map[string]*Resource{"videos": {Methods: map[string]*Method{"list": {}}}},
+	}
+	if _, err := GenerateMCPTools(doc, GenerateOptions{Prefix: "yt/"}); err == nil {
+		t.Error("GenerateMCPTools with an illegal prefix should fail")
+	}
+}
+
+func TestGenerateMCPToolsRequestAndResponseSchemas(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {
+				ID:   "Video",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":      {Type: "string"},
+					"snippet": {Ref: "VideoSnippet"},
+				},
+			},
+			"VideoSnippet": {
+				ID:         "VideoSnippet",
+				Type:       "object",
+				Properties: map[string]*Schema{"title": {Type: "string"}},
+			},
+			"VideoListResponse": {
+				ID:   "VideoListResponse",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"nextPageToken": {Type: "string"},
+					"pageInfo":      {Ref: "PageInfo"},
+					"items":         {Type: "array", Items: &Schema{Ref: "Video"}},
+				},
+			},
+			"PageInfo": {
+				ID:         "PageInfo",
+				Type:       "object",
+				Properties: map[string]*Schema{"totalResults": {Type: "integer", Format: "int32"}},
+			},
+		},
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"search": {
+						ID:       "youtube.videos.search",
+						Request:  &SchemaRef{Ref: "Video"},
+						Response: &SchemaRef{Ref: "VideoListResponse"},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+
+	// Request side.
+	for _, want := range []string{"type Video struct", "type VideoSnippet struct"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("request schema missing %q", want)
+		}
+	}
+	// Response side, which only the response references.
+	for _, want := range []string{"type VideoListResponse struct", "type PageInfo struct"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("response schema missing %q", want)
+		}
+	}
+	if !containsFieldType(code, "NextPageToken", "string") {
+		t.Error("VideoListResponse.NextPageToken should be string")
+	}
+	if !containsFieldType(code, "PageInfo", "*PageInfo") {
+		t.Error("VideoListResponse.PageInfo should be *PageInfo")
+	}
+	if !containsFieldType(code, "Items", "[]*Video") {
+		t.Error("VideoListResponse.Items should be []*Video")
+	}
+	if t.Failed() {
+		t.Logf("Generated code:\n%s", code)
+	}
+}
+
+func TestGenerateMCPToolsResponseMetadata(t *testing.T) {
+	doc := &Document{
+		Name: "drive",
+		Resources: map[string]*Resource{
+			"files": {
+				Methods: map[string]*Method{
+					"get": {
+						ID:                    "drive.files.get",
+						Response:              &SchemaRef{Ref: "File"},
+						SupportsMediaDownload: true,
+					},
+					"list":   {ID: "drive.files.list", Response: &SchemaRef{Ref: "FileList"}},
+					"delete": {ID: "drive.files.delete"},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		"var GeneratedToolResponses = map[string]struct {",
+		`"drive_files_get":  {RequestType: "", ResponseType: "File", MediaDownload: true},`,
+		`"drive_files_list": {RequestType: "", ResponseType: "FileList", MediaDownload: false},`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, `"drive_files_delete": {`) {
+		t.Error("methods without a response or media download should have no metadata entry")
+	}
+
+	delete(doc.Resources["files"].Methods, "get")
+	delete(doc.Resources["files"].Methods, "list")
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "GeneratedToolResponses") {
+		t.Error("GeneratedToolResponses should be omitted when no method has response metadata")
+	}
+}
+
+func TestTitleSlug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"YouTube Data API", "youtube_data_api"},
+		{"YouTube Data API v3", "youtube_data_api_v3"},
+		{"  Cloud Storage JSON API (beta)  ", "cloud_storage_json_api_beta"},
+		{"Google Drive™ — Files", "google_drive_files"},
+		{"", ""},
+		{"---", ""},
+	}
+	for _, tt := range tests {
+		if got := titleSlug(tt.title); got != tt.want {
+			t.Errorf("titleSlug(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateMCPToolsPrefixFromTitle(t *testing.T) {
+	doc := &Document{
+		Name:      "yt",
+		Title:     "YouTube Data API",
+		Resources: map[string]*Resource{"videos": {Methods: map[string]*Method{"list": {}}}},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PrefixFromTitle: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, `"youtube_data_api_videos_list"`) {
+		t.Errorf("tool name should use the title slug prefix\nGenerated code:\n%s", code)
+	}
+
+	// An explicit prefix still wins, and an empty title falls back to Name.
+	code, err = GenerateMCPTools(doc, GenerateOptions{PrefixFromTitle: true, Prefix: "x_"})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, `"x_videos_list"`) {
+		t.Error("explicit Prefix should override PrefixFromTitle")
+	}
+	doc.Title = ""
+	code, err = GenerateMCPTools(doc, GenerateOptions{PrefixFromTitle: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, `"yt_videos_list"`) {
+		t.Error("empty title should fall back to the API name")
+	}
+}
+
+func TestSortedParamsRequiredAnnotation(t *testing.T) {
+	m := &MethodInfo{
+		FullName:     "videos.insert",
+		StructPrefix: "API",
+		Method: &Method{
+			ID: "youtube.videos.insert",
+			Parameters: map[string]*Parameter{
+				"autoLevels": {Type: "boolean"},
+				"part": {
+					Type:        "string",
+					Annotations: &Annotations{Required: []string{"youtube.videos.insert", "youtube.videos.update"}},
+				},
+				"notifySubscribers": {
+					Type:        "boolean",
+					Annotations: &Annotations{Required: []string{"youtube.videos.update"}},
+				},
+			},
+		},
+	}
+
+	byName := func(name string) *ParamInfo {
+		for _, p := range m.SortedParams() {
+			if p.Name == name {
+				return p
+			}
+		}
+		t.Fatalf("no parameter %s", name)
+		return nil
+	}
+	// Scalars required only by annotation are plain values, not optional pointers.
+	m.Method.Parameters["stabilize"] = &Parameter{Type: "boolean", Annotations: &Annotations{Required: []string{"youtube.videos.insert"}}}
+	m.Method.Parameters["quota"] = &Parameter{Type: "integer", Format: "int32", Annotations: &Annotations{Required: []string{"youtube.videos.insert"}}}
+	m.Options = &GenerateOptions{OptionalAsPointer: true}
+	for name, want := range map[string]string{"stabilize": "bool", "quota": "int32", "notifySubscribers": "*bool"} {
+		p := byName(name)
+		if got := p.GoType(); got != want {
+			t.Errorf("%s GoType() = %q, want %q", name, got, want)
+		}
+		if omit := strings.HasSuffix(p.JSONTag(), ",omitempty"); omit != (want[0] == '*') {
+			t.Errorf("%s JSONTag() = %q, inconsistent with GoType %q", name, p.JSONTag(), want)
+		}
+	}
+	delete(m.Method.Parameters, "stabilize")
+	delete(m.Method.Parameters, "quota")
+	m.Options = nil
+
+	params := m.SortedParams()
+	if params[0].Name != "part" {
+		t.Fatalf("first param = %q, want part (required via annotation)", params[0].Name)
+	}
+	if !params[0].Required() {
+		t.Error("part should be required")
+	}
+	if got := params[0].JSONTag(); got != "part" {
+		t.Errorf("part JSONTag() = %q, want no omitempty", got)
+	}
+	for _, p := range params[1:] {
+		if p.Required() {
+			t.Errorf("%s should be optional for youtube.videos.insert", p.Name)
+		}
+	}
+}
+
+func TestSortedParamsNestedPath(t *testing.T) {
+	path := "tagmanager/v2/accounts/{accountId}/containers/{containerId}/workspaces/{workspaceId}/tags"
+	params := map[string]*Parameter{
+		"workspaceId": {Type: "string", Location: "path", Required: true},
+		"accountId":   {Type: "string", Location: "path", Required: true},
+		"containerId": {Type: "string", Location: "path", Required: true},
+		"alpha":       {Type: "string", Location: "query", Required: true},
+		"pageToken":   {Type: "string", Location: "query"},
+	}
+	tests := []struct {
+		name  string
+		order []string
+	}{
+		{"parameterOrder", []string{"accountId", "containerId", "workspaceId"}},
+		{"partial parameterOrder", []string{"accountId"}},
+		{"no parameterOrder", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MethodInfo{
+				FullName:     "accounts.containers.workspaces.tags.create",
+				StructPrefix: "API",
+				Method:       &Method{ID: "tagmanager.accounts.containers.workspaces.tags.create", Path: path, Parameters: params, ParameterOrder: tt.order},
+			}
+			var names []string
+			for _, p := range m.SortedParams() {
+				names = append(names, p.Name)
+			}
+			if got, want := strings.Join(names, ","), "accountId,containerId,workspaceId,alpha,pageToken"; got != want {
+				t.Errorf("SortedParams = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestGenerateMCPToolsMarshalJSON(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {
+				ID:   "Video",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":      {Type: "string"},
+					"tags":    {Type: "array", Items: &Schema{Type: "string"}},
+					"status":  {Ref: "VideoStatus"},
+					"snippet": {Ref: "VideoSnippet"},
+				},
+			},
+			"VideoStatus": {
+				ID:   "VideoStatus",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"madeForKids": {Type: "boolean"},
+					"embeddable":  {Type: "boolean"},
+				},
+			},
+			"VideoSnippet": {
+				ID:         "VideoSnippet",
+				Type:       "object",
+				Properties: map[string]*Schema{"title": {Type: "string"}},
+			},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{"update": {ID: "youtube.videos.update", Request: &SchemaRef{Ref: "Video"}}}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateSchema: true, GenerateMarshalJSON: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "func (v Video) MarshalJSON() ([]byte, error)") {
+		t.Fatalf("MarshalJSON should be generated\nGenerated code:\n%s", code)
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	for _, v := range []any{
+		Video{},
+		&Video{Status: &VideoStatus{}, Snippet: &VideoSnippet{}},
+		Video{ID: "abc", Status: &VideoStatus{MadeForKids: new(bool)}},
+	} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	}
+}
+`,
+	})
+
+	want := "{}\n{}\n{\"id\":\"abc\",\"status\":{\"madeForKids\":false}}\n"
+	if out != want {
+		t.Errorf("marshaled output = %q, want %q", out, want)
+	}
+}
+
+func TestGenerateMCPToolsAssertions(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{"list": {}, "insert": {}}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateAssertions: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		"var _ = map[string]any{",
+		`"youtube_videos_insert": APIVideosInsertArgs{},`,
+		`"youtube_videos_list":   APIVideosListArgs{},`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "var _ = map[string]any{") {
+		t.Error("assertions should only be generated with GenerateAssertions")
+	}
+}
+
+func TestCleanDescription(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"multi\nline\r\ntext", "multi line text"},
+		{"tab\tseparated", "tab separated"},
+		{`say "hi"`, "say 'hi'"},
+		{"use `code`", "use 'code'"},
+		{`C:\path\to`, `C:\path\to`},
+		{`escaped \" quote`, `escaped \' quote`},
+		{`trailing backslash\`, `trailing backslash\`},
+		{"nul\x00and\x7fdel", "nul and del"},
+		{"\ufeffbom", "bom"},
+		{"bad \xff utf8", "bad utf8"},
+		{"  spaced   out  ", "spaced out"},
+	}
+	for _, tt := range tests {
+		if got := cleanDescription(tt.in); got != tt.want {
+			t.Errorf("cleanDescription(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateMCPToolsBackslashDescriptions(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{
+				"path": {Type: "string", Description: `Like C:\dir\`},
+			}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"insert": {
+					ID:          "youtube.videos.insert",
+					Description: `Matches \d+`,
+					Request:     &SchemaRef{Ref: "Video"},
+					Parameters:  map[string]*Parameter{"glob": {Type: "string", Description: `Escape * as \*`}},
+				},
+			}},
+		},
+	}
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, EscapeMarkdown: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		`jsonschema:"Like C:\\dir\\"`,
+		`jsonschema:"Escape * as \\*"`,
+		"// Matches \\d+",                             // Comments keep the text as written
+		"\"youtube_videos_insert\": `Matches \\\\d+`", // Markdown shows the backslash
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %s\nGenerated code:\n%s", want, code)
+		}
+	}
+	tag := reflect.StructTag(`jsonschema:"Like C:\\dir\\"`)
+	if got := tag.Get("jsonschema"); got != `Like C:\dir\` {
+		t.Errorf("escaped tag reads back as %q", got)
+	}
+}
+
+// TestGenerateMCPToolsHostileDescriptions feeds randomly assembled descriptions
+// full of quoting, escape and control characters through the generator and checks
+// that the output always parses and every struct tag stays well-formed.
+func TestGenerateMCPToolsHostileDescriptions(t *testing.T) {
+	pieces := []string{"a", "Z", " ", "\\", `"`, "`", "\t", "\n", "\r", "\x00", "\x1b", "'", ",", ":", "\u2028", "\ufeff", "\xff", "é", "*/", "//"}
+	rng := rand.New(rand.NewSource(1))
+	randomText := func() string {
+		var b strings.Builder
+		for n := rng.Intn(12); n >= 0; n-- {
+			b.WriteString(pieces[rng.Intn(len(pieces))])
+		}
+		return b.String()
+	}
+
+	for i := 0; i < 200; i++ {
+		doc := &Document{
+			Name: "youtube",
+			Schemas: map[string]*Schema{
+				"Video": {
+					ID:          "Video",
+					Type:        "object",
+					Description: randomText(),
+					Properties: map[string]*Schema{
+						"title": {Type: "string", Description: randomText(), Default: randomText()},
+						"kind":  {Type: "string", Description: randomText(), Enum: []string{randomText(), randomText()}},
+					},
+				},
+			},
+			Resources: map[string]*Resource{
+				"videos": {
+					Methods: map[string]*Method{
+						"insert": {
+							ID:          "youtube.videos.insert",
+							Description: randomText(),
+							Request:     &SchemaRef{Ref: "Video"},
+							Parameters: map[string]*Parameter{
+								"part": {Type: "string", Required: true, Description: randomText()},
+								"mode": {Type: "string", Description: randomText(), Default: randomText(), Enum: []string{randomText()}},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+		if err != nil {
+			t.Fatalf("iteration %d: GenerateMCPTools failed: %v", i, err)
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), "tools.go", code, 0)
+		if err != nil {
+			t.Fatalf("iteration %d: generated code does not parse: %v\n%s", i, err, code)
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			field, ok := n.(*ast.Field)
+			if !ok || field.Tag == nil {
+				return true
+			}
+			raw, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				t.Fatalf("iteration %d: unquoting tag %s: %v", i, field.Tag.Value, err)
+			}
+			for _, key := range []string{"json", "jsonschema"} {
+				if _, ok := reflect.StructTag(raw).Lookup(key); !ok {
+					t.Errorf("iteration %d: tag %s has no well-formed %s key", i, field.Tag.Value, key)
+				}
+			}
+			return true
+		})
+	}
+}
+
+func TestGenerateMCPToolsRawMessageForAny(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Event": {
+				ID:   "Event",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"payload":  {Type: "any"},
+					"metadata": {Type: "object"},
+					"items":    {Type: "array", Items: &Schema{Type: "any"}},
+					"labels":   {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+					"name":     {Type: "string"},
+				},
+			},
+		},
+		Resources: map[string]*Resource{
+			"events": {Methods: map[string]*Method{"get": {Response: &SchemaRef{Ref: "Event"}}}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, RawMessageForAny: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for field, want := range map[string]string{
+		"Payload":  "json.RawMessage",
+		"Metadata": "json.RawMessage",
+		"Items":    "[]json.RawMessage",
+		"Labels":   "map[string]string",
+		"Name":     "string",
+	} {
+		if !containsFieldType(code, field, want) {
+			t.Errorf("%s should be %s", field, want)
+		}
+	}
+	if !strings.Contains(code, `"encoding/json"`) {
+		t.Error("encoding/json should be imported")
+	}
+	if t.Failed() {
+		t.Logf("Generated code:\n%s", code)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !containsFieldType(code, "Payload", "any") || strings.Contains(code, "json.RawMessage") {
+		t.Error("without RawMessageForAny, freeform properties should stay any")
+	}
+}
+
+func TestGenerateTestFile(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {
+				ID:   "Video",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":     {Type: "string"},
+					"status": {Ref: "VideoStatus"},
+				},
+			},
+			"VideoStatus": {
+				ID:         "VideoStatus",
+				Type:       "object",
+				Properties: map[string]*Schema{"madeForKids": {Type: "boolean"}},
+			},
+		},
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list":   {Parameters: map[string]*Parameter{"part": {Type: "string", Required: true}}},
+					"insert": {Request: &SchemaRef{Ref: "Video"}, Response: &SchemaRef{Ref: "Video"}},
+				},
+			},
+		},
+	}
+
+	files, err := GenerateFiles(doc, GenerateOptions{GenerateSchema: true, GenerateTests: true})
+	if err != nil {
+		t.Fatalf("GenerateFiles failed: %v", err)
+	}
+	testFile := files["tools_test.go"]
+	for _, name := range []string{"APIVideosListArgs", "APIVideosInsertArgs", "Video", "VideoStatus"} {
+		if !regexp.MustCompile(`"` + name + `":\s+&` + name + `\{\},`).MatchString(testFile) {
+			t.Errorf("tools_test.go should reference %s\n%s", name, testFile)
+		}
+	}
+
+	out := runGo(t, files, "test", "-count=1", "-v", ".")
+	if !strings.Contains(out, "--- PASS: TestGeneratedTypesRoundTrip/VideoStatus") {
+		t.Errorf("generated tests did not run as expected:\n%s", out)
+	}
+}
+
+func TestGenerateMCPToolsSchemaOnly(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {
+				ID:   "Video",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":     {Type: "string"},
+					"status": {Type: "string", Enum: []string{"public", "private"}},
+				},
+			},
+		},
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						Response:   &SchemaRef{Ref: "Video"},
+						Parameters: map[string]*Parameter{"chart": {Type: "string", Enum: []string{"mostPopular", "trending"}}},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{SchemaOnly: true, GenerateEnums: true, GenerateHandlers: true, GenerateTests: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "type Video struct") {
+		t.Error("schema-only output should contain the schema types")
+	}
+	for _, unwanted := range []string{"APIVideosListArgs", "GeneratedToolDefinitions", "GeneratedToolResponses", "RegisterTools", "mostPopular"} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("schema-only output should not contain %q", unwanted)
+		}
+	}
+	if t.Failed() {
+		t.Logf("Generated code:\n%s", code)
+	}
+
+	testFile, err := GenerateTestFile(doc, GenerateOptions{SchemaOnly: true})
+	if err != nil {
+		t.Fatalf("GenerateTestFile failed: %v", err)
+	}
+	if strings.Contains(testFile, "APIVideosListArgs") || !strings.Contains(testFile, "&Video{}") {
+		t.Errorf("schema-only test file should only cover schema types:\n%s", testFile)
+	}
+}
+
+func TestGenerateMCPToolsAllSchemas(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video":    {ID: "Video", Type: "object", Properties: map[string]*Schema{"id": {Type: "string"}}},
+			"Playlist": {ID: "Playlist", Type: "object", Properties: map[string]*Schema{"id": {Type: "string"}}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{"get": {Response: &SchemaRef{Ref: "Video"}}}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "type Playlist struct") {
+		t.Error("unreferenced Playlist should not be generated by default")
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, AllSchemas: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{"type Playlist struct", "type Video struct"} {
+		if strings.Count(code, want) != 1 {
+			t.Errorf("expected exactly one %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	if strings.Index(code, "type Playlist struct") > strings.Index(code, "type Video struct") {
+		t.Error("schemas should be emitted in sorted order")
+	}
+}
+
+func TestGenerateMCPToolsGenericListResponse(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{"id": {Type: "string"}}},
+			"VideoListResponse": {
+				ID:   "VideoListResponse",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"items":         {Type: "array", Items: &Schema{Ref: "Video"}},
+					"nextPageToken": {Type: "string"},
+				},
+			},
+			"PlaylistListResponse": {
+				ID:   "PlaylistListResponse",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"items":         {Type: "array", Items: &Schema{Ref: "Video"}},
+					"nextPageToken": {Type: "string"},
+					"totalResults":  {Type: "integer", Format: "int32"},
+				},
+			},
+		},
+		Resources: map[string]*Resource{
+			"videos":    {Methods: map[string]*Method{"list": {Response: &SchemaRef{Ref: "VideoListResponse"}}}},
+			"playlists": {Methods: map[string]*Method{"list": {Response: &SchemaRef{Ref: "PlaylistListResponse"}}}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateSchema: true, GenericListResponse: true, GenerateMarshalJSON: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		"type ListResponse[T any] struct {",
+		"type VideoListResponse = ListResponse[Video]",
+		// Extra fields keep a struct, which embeds the common ones.
+		"type PlaylistListResponse struct {\n\tListResponse[Video]\n\tTotalResults int32 ",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var resp VideoListResponse
+	if err := json.Unmarshal([]byte(` + "`" + `{"items": [{"id": "a"}, {"id": "b"}], "nextPageToken": "p2"}` + "`" + `), &resp); err != nil {
+		panic(err)
+	}
+	fmt.Println(len(resp.Items), resp.Items[1].ID, resp.NextPageToken)
+
+	var playlists PlaylistListResponse
+	if err := json.Unmarshal([]byte(` + "`" + `{"items": [{"id": "c"}], "nextPageToken": "p3", "totalResults": 7}` + "`" + `), &playlists); err != nil {
+		panic(err)
+	}
+	data, err := json.Marshal(playlists)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(playlists.Items[0].ID, playlists.NextPageToken, playlists.TotalResults, string(data))
+}
+`,
+	})
+	want := "2 b p2\n" + `c p3 7 {"items":[{"id":"c"}],"nextPageToken":"p3","totalResults":7}` + "\n"
+	if out != want {
+		t.Errorf("decoded list responses = %q, want %q", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "ListResponse[") {
+		t.Error("generic list responses should only be generated with GenericListResponse")
+	}
+}
+
+func TestGenerateMCPToolsRepeatedPathParam(t *testing.T) {
+	doc := &Document{
+		Name: "people",
+		Resources: map[string]*Resource{
+			"people": {
+				Methods: map[string]*Method{
+					"batchGet": {
+						ID:             "people.people.batchGet",
+						Path:           "v1/people/{resourceNames}",
+						ParameterOrder: []string{"resourceNames"},
+						Parameters: map[string]*Parameter{
+							"resourceNames": {Type: "string", Location: "path", Required: true, Repeated: true},
+							"personFields":  {Type: "string", Location: "query"},
+						},
+					},
+					"get": {
+						Parameters: map[string]*Parameter{"resourceName": {Type: "string", Location: "path", Required: true}},
+					},
+				},
+			},
+		},
+	}
+
+	m := &MethodInfo{FullName: "people.batchGet", Method: doc.Resources["people"].Methods["batchGet"]}
+	params := m.SortedParams()
+	if len(params) != 2 || params[0].Name != "resourceNames" {
+		t.Fatalf("SortedParams() = %v, want resourceNames first", params)
+	}
+	if got := params[0].GoType(); got != "[]string" {
+		t.Errorf("repeated path param GoType() = %q, want []string", got)
+	}
+	if !params[0].RepeatedPath() || params[1].RepeatedPath() {
+		t.Error("only resourceNames should be a repeated path parameter")
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !containsFieldType(code, "ResourceNames", "[]string") {
+		t.Error("ResourceNames should be []string")
+	}
+	if !strings.Contains(code, `"people_people_batchGet": {"resourceNames"},`) {
+		t.Errorf("repeated path parameter should be flagged\nGenerated code:\n%s", code)
+	}
+	if strings.Contains(code, `"people_people_get": {`) {
+		t.Error("non-repeated path parameters should not be flagged")
+	}
+}
+
+func TestJSONTagCase(t *testing.T) {
+	tests := []struct {
+		name  string
+		snake string
+		camel string
+	}{
+		{"maxResults", "max_results", "maxResults"},
+		{"onBehalfOfContentOwner", "on_behalf_of_content_owner", "onBehalfOfContentOwner"},
+		{"videoHTMLId", "video_html_id", "videoHTMLId"},
+		{"part", "part", "part"},
+		{"max_results", "max_results", "maxResults"},
+		{"upload-type", "upload_type", "uploadType"},
+		{"v2Name", "v2_name", "v2Name"},
+	}
+	for _, tt := range tests {
+		if got := snakeCase(tt.name); got != tt.snake {
+			t.Errorf("snakeCase(%q) = %q, want %q", tt.name, got, tt.snake)
+		}
+		if got := camelCase(tt.name); got != tt.camel {
+			t.Errorf("camelCase(%q) = %q, want %q", tt.name, got, tt.camel)
+		}
+	}
+
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{"viewCount": {Type: "string"}}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						Response: &SchemaRef{Ref: "Video"},
+						Parameters: map[string]*Parameter{
+							"maxResults": {Type: "integer", Format: "uint32"},
+							"part":       {Type: "string", Required: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, JSONTagCase: JSONTagCaseSnake})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{`json:"max_results,omitempty"`, `json:"part"`, `json:"view_count,omitempty"`} {
+		if !strings.Contains(code, want) {
+			t.Errorf("snake case output missing %s\nGenerated code:\n%s", want, code)
+		}
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, `json:"maxResults,omitempty"`) {
+		t.Error("default output should keep the wire names")
+	}
+
+	if _, err := GenerateMCPTools(doc, GenerateOptions{JSONTagCase: "kebab"}); err == nil {
+		t.Error("unknown JSONTagCase should be rejected")
+	}
+}
+
+func TestWrapComment(t *testing.T) {
+	got := wrapComment("The quick brown fox jumps over the lazy dog", 15)
+	want := []string{"The quick brown", "fox jumps over", "the lazy dog"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapComment = %q, want %q", got, want)
+	}
+	if got := wrapComment("supercalifragilistic word", 10); strings.Join(got, "|") != "supercalifragilistic|word" {
+		t.Errorf("long words should get their own line, got %q", got)
+	}
+	if got := wrapComment("  ", 10); got != nil {
+		t.Errorf("blank text should produce no lines, got %q", got)
+	}
+}
+
+func TestGenerateMCPToolsFieldComments(t *testing.T) {
+	long := "The video's privacy status. Private videos are only visible to the owner and explicitly shared users, while unlisted videos can be seen by anyone with the link."
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"VideoStatus": {
+				ID:   "VideoStatus",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"privacyStatus": {Type: "string", Description: long},
+					"embeddable":    {Type: "boolean"},
+				},
+			},
+		},
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"list": {
+						Response:   &SchemaRef{Ref: "VideoStatus"},
+						Parameters: map[string]*Parameter{"maxResults": {Type: "integer", Description: "Maximum number of items to return."}},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, FieldComments: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, `jsonschema:"The video's privacy status.`) {
+		t.Error("description should still be in the struct tag")
+	}
+	wantComment := "\t// The video's privacy status. Private videos are only visible to the owner and\n" +
+		"\t// explicitly shared users, while unlisted videos can be seen by anyone with\n" +
+		"\t// the link.\n" +
+		"\tPrivacyStatus "
+	if !strings.Contains(code, wantComment) {
+		t.Errorf("wrapped comment should precede the field\nGenerated code:\n%s", code)
+	}
+	if !strings.Contains(code, "\t// Maximum number of items to return.\n\tMaxResults ") {
+		t.Error("parameters should get comments too")
+	}
+	if regexp.MustCompile(`//\s*\n\s*Embeddable`).MatchString(code) {
+		t.Error("fields without a description should get no comment")
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "\t// The video's privacy status.") {
+		t.Error("field comments should only be generated with FieldComments")
+	}
+}
+
+func TestGenerateMCPToolsAliasDuplicates(t *testing.T) {
+	props := func() map[string]*Schema {
+		return map[string]*Schema{
+			"id":    {Type: "string"},
+			"count": {Type: "integer", Format: "int32"},
+		}
+	}
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Thumbnail":      {ID: "Thumbnail", Type: "object", Properties: props()},
+			"ThumbnailCopy":  {ID: "ThumbnailCopy", Type: "object", Properties: props()},
+			"ThumbnailOther": {ID: "ThumbnailOther", Type: "object", Properties: map[string]*Schema{"id": {Type: "string"}, "count": {Type: "string"}}},
+		},
+	}
+	opts := GenerateOptions{PackageName: "main", GenerateSchema: true, AllSchemas: true, AliasDuplicates: true}
+
+	code, err := GenerateMCPTools(doc, opts)
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		"type Thumbnail struct {",
+		"type ThumbnailCopy = Thumbnail",
+		"type ThumbnailOther struct {",
+	} {
+		if strings.Count(code, want) != 1 {
+			t.Errorf("expected exactly one %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "type ThumbnailCopy struct") {
+		t.Error("duplicate schema should not get its own struct")
+	}
+
+	opts.GenerateMarshalJSON = true
+	code, err = GenerateMCPTools(doc, opts)
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	var c ThumbnailCopy = Thumbnail{ID: "a", Count: 2}
+	fmt.Println(c.ID, c.Count)
+}
+`,
+	})
+	if out != "a 2\n" {
+		t.Errorf("output = %q, want %q", out, "a 2\n")
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, AllSchemas: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "type ThumbnailCopy struct") {
+		t.Error("duplicates should only be aliased with AliasDuplicates")
+	}
+}
+
+func TestGenerateMCPToolsByteAsBytes(t *testing.T) {
+	doc := &Document{
+		Name: "vision",
+		Schemas: map[string]*Schema{
+			"Image": {
+				ID:   "Image",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"content":  {Type: "string", Format: "byte"},
+					"checksum": {Type: "string", Format: "int64"},
+					"size":     {Type: "integer", Format: "byte"},
+					"chunks":   {Type: "array", Items: &Schema{Type: "string", Format: "byte"}},
+				},
+			},
+		},
+	}
+	opts := GenerateOptions{PackageName: "main", GenerateSchema: true, AllSchemas: true, ByteAsBytes: true, OptionalAsPointer: true}
+
+	code, err := GenerateMCPTools(doc, opts)
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for field, want := range map[string]string{
+		"Content":  "[]byte",
+		"Chunks":   "[][]byte",
+		"Checksum": "*string",
+		"Size":     "*int64",
+	} {
+		if !containsFieldType(code, field, want) {
+			t.Errorf("%s should be %s\nGenerated code:\n%s", field, want, code)
+		}
+	}
+	if !strings.Contains(code, `json:"content,omitempty"`) {
+		t.Errorf("content should keep its json tag\nGenerated code:\n%s", code)
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	data, _ := json.Marshal(Image{Content: []byte("hi")})
+	var img Image
+	if err := json.Unmarshal(data, &img); err != nil {
+		panic(err)
+	}
+	fmt.Println(string(data), string(img.Content))
+}
+`,
+	})
+	if want := `{"content":"aGk="} hi` + "\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, AllSchemas: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !containsFieldType(code, "Content", "string") {
+		t.Error("byte fields should stay string without ByteAsBytes")
+	}
+}
+
+func TestGenerateMCPToolsFieldMask(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {
+				ID:   "Video",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":      {Type: "string"},
+					"snippet": {Ref: "VideoSnippet"},
+					"tags":    {Type: "array", Items: &Schema{Type: "string"}},
+				},
+			},
+			"VideoSnippet": {ID: "VideoSnippet", Type: "object", Properties: map[string]*Schema{"title": {Type: "string"}}},
+			"Playlist":     {ID: "Playlist", Type: "object", Properties: map[string]*Schema{"id": {Type: "string"}}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"patch": {ID: "youtube.videos.patch", HTTPMethod: "PATCH", Request: &SchemaRef{Ref: "Video"}, Response: &SchemaRef{Ref: "Video"}},
+			}},
+			"playlists": {Methods: map[string]*Method{
+				"insert": {ID: "youtube.playlists.insert", HTTPMethod: "POST", Request: &SchemaRef{Ref: "Playlist"}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateSchema: true, GenerateFieldMask: true, JSONTagCase: JSONTagCaseSnake})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "func (v *Video) FieldMask() string") {
+		t.Errorf("PATCH request body should get FieldMask\nGenerated code:\n%s", code)
+	}
+	for _, name := range []string{"Playlist", "VideoSnippet"} {
+		if strings.Contains(code, "func (v *"+name+") FieldMask()") {
+			t.Errorf("%s is not an update request body and should not get FieldMask", name)
+		}
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	v := &Video{Snippet: &VideoSnippet{Title: "new"}}
+	fmt.Println(v.FieldMask())
+	v.Tags = []string{"a"}
+	fmt.Println(v.FieldMask())
+	fmt.Printf("%q\n", (&Video{}).FieldMask())
+}
+`,
+	})
+	if want := "snippet\nsnippet,tags\n\"\"\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "FieldMask") {
+		t.Error("FieldMask should only be generated with GenerateFieldMask")
+	}
+}
+
+func TestGenerateMCPToolsMissingName(t *testing.T) {
+	resources := map[string]*Resource{
+		"videos": {Methods: map[string]*Method{"list": {ID: "youtube.videos.list"}}},
+	}
+	tests := []struct {
+		name string
+		doc  *Document
+		want string
+	}{
+		{"title only", &Document{Title: "YouTube Data API", Version: "v3", Resources: resources}, `"youtube_data_api_videos_list"`},
+		{"id", &Document{ID: "youtube:v3", Title: "YouTube Data API", Resources: resources}, `"youtube_videos_list"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := GenerateMCPTools(tt.doc, GenerateOptions{})
+			if err != nil {
+				t.Fatalf("GenerateMCPTools failed: %v", err)
+			}
+			if !strings.Contains(code, tt.want) {
+				t.Errorf("expected tool name %s\nGenerated code:\n%s", tt.want, code)
+			}
+			if strings.Contains(code, `"_videos_list"`) {
+				t.Error("tool names should not start with an underscore")
+			}
+		})
+	}
+
+	if _, err := GenerateMCPTools(&Document{Resources: resources}, GenerateOptions{}); err == nil || !strings.Contains(err.Error(), "no name") {
+		t.Errorf("GenerateMCPTools without name, id or title = %v, want a no name error", err)
+	}
+}
+
+func TestGenerateMCPToolsEnumValues(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {
+				ID:   "Video",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"privacy": {Type: "string", Enum: EnumValues{"private", "public"}},
+					"regions": {Type: "array", Items: &Schema{Type: "string", Enum: EnumValues{"US", "DE"}}},
+					"title":   {Type: "string"},
+				},
+			},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{"list": {
+				ID:       "youtube.videos.list",
+				Response: &SchemaRef{Ref: "Video"},
+				Parameters: map[string]*Parameter{
+					"chart": {Type: "string", Location: "query", Enum: EnumValues{"chartUnspecified", "mostPopular"}},
+					"part":  {Type: "string", Location: "query", Required: true},
+				},
+			}}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateSchema: true, GenerateEnumValues: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		"var GeneratedEnumValues = map[string][]string{",
+		`"APIVideosListArgs.Chart": {"chartUnspecified", "mostPopular"},`,
+		`"Video.Privacy":           {"private", "public"},`,
+		`"Video.Regions":           {"US", "DE"},`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	for _, unwanted := range []string{`"APIVideosListArgs.Part"`, `"Video.Title"`} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("%s has no enum and should not be listed", unwanted)
+		}
+	}
+	runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go":  "package main\n\nfunc main() { _ = GeneratedEnumValues }\n",
+	})
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "GeneratedEnumValues") {
+		t.Error("GeneratedEnumValues should only be generated with GenerateEnumValues")
+	}
+}
+
+func TestGenerateMCPToolsNestedAdditionalProperties(t *testing.T) {
+	doc := &Document{
+		Name: "tagmanager",
+		Schemas: map[string]*Schema{
+			"Container": {
+				ID:   "Container",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"tagsByZone": {
+						Type:                 "object",
+						AdditionalProperties: &Schema{Type: "array", Items: &Schema{Ref: "Tag"}},
+					},
+					"triggersByZoneAndName": {
+						Type: "object",
+						AdditionalProperties: &Schema{
+							Type:                 "object",
+							AdditionalProperties: &Schema{Type: "array", Items: &Schema{Ref: "Trigger"}},
+						},
+					},
+				},
+			},
+			"Tag":     {ID: "Tag", Type: "object", Properties: map[string]*Schema{"name": {Type: "string"}}},
+			"Trigger": {ID: "Trigger", Type: "object", Properties: map[string]*Schema{"name": {Type: "string"}}},
+		},
+		Resources: map[string]*Resource{
+			"containers": {Methods: map[string]*Method{"get": {Response: &SchemaRef{Ref: "Container"}}}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !containsFieldType(code, "TagsByZone", "map[string][]*Tag") {
+		t.Errorf("TagsByZone should be map[string][]*Tag\nGenerated code:\n%s", code)
+	}
+	if !containsFieldType(code, "TriggersByZoneAndName", "map[string]map[string][]*Trigger") {
+		t.Errorf("TriggersByZoneAndName should be map[string]map[string][]*Trigger\nGenerated code:\n%s", code)
+	}
+	for _, want := range []string{"type Tag struct", "type Trigger struct"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("schema referenced through additionalProperties items not collected: missing %q", want)
+		}
+	}
+}
+
+func TestGenerateToolsJSON(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := GenerateToolsJSON(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateToolsJSON failed: %v", err)
+	}
+	var tools map[string]ToolMapping
+	if err := json.Unmarshal([]byte(out), &tools); err != nil {
+		t.Fatalf("tools.json is not valid JSON: %v\n%s", err, out)
+	}
+	want := ToolMapping{Struct: "APIVideosListArgs", HTTPMethod: "GET", Path: "youtube/v3/videos"}
+	if got := tools["youtube_videos_list"]; got != want {
+		t.Errorf("youtube_videos_list = %+v, want %+v", got, want)
+	}
+	if len(tools) != 3 {
+		t.Errorf("got %d tools, want 3", len(tools))
+	}
+
+	files, err := GenerateFiles(doc, GenerateOptions{ToolsJSON: true})
+	if err != nil {
+		t.Fatalf("GenerateFiles failed: %v", err)
+	}
+	if files["tools.json"] != out {
+		t.Error("GenerateFiles should include the same tools.json with ToolsJSON")
+	}
+	if files, _ := GenerateFiles(doc, GenerateOptions{}); files["tools.json"] != "" {
+		t.Error("tools.json should only be generated with ToolsJSON")
+	}
+}
+
+func TestValidatePackageName(t *testing.T) {
+	doc := &Document{Name: "youtube"}
+	for _, name := range []string{"foobar", "youtube_v3", "v3"} {
+		if _, err := GenerateMCPTools(doc, GenerateOptions{PackageName: name}); err != nil {
+			t.Errorf("package name %q rejected: %v", name, err)
+		}
+	}
+	for _, name := range []string{"foo-bar", "foo/bar", "foo.bar", "3d", "type", "_"} {
+		_, err := GenerateMCPTools(doc, GenerateOptions{PackageName: name})
+		if err == nil || !strings.Contains(err.Error(), "invalid package name") {
+			t.Errorf("package name %q: err = %v, want invalid package name error", name, err)
+		}
+	}
+	if _, err := GenerateRegistryFile("foo-bar"); err == nil {
+		t.Error("GenerateRegistryFile should reject invalid package names")
+	}
+}
+
+func TestGenerateMCPToolsDescribe(t *testing.T) {
+	long := "Returns a list of videos that match the API request parameters. " + strings.Repeat("More detail. ", 20)
+	doc := &Document{
+		Name: "youtube",
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"list":   {ID: "youtube.videos.list", Description: long},
+				"delete": {ID: "youtube.videos.delete", Description: "Deletes a \"video\"."},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateDescribe: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "func (APIVideosListArgs) Describe() string {") {
+		t.Fatalf("Describe method missing\nGenerated code:\n%s", code)
+	}
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(APIVideosDeleteArgs{}.Describe())
+	fmt.Println(APIVideosListArgs{}.Describe() == GeneratedToolDefinitions["youtube_videos_list"])
+	fmt.Println(len(APIVideosListArgs{}.Describe()))
+}
+`,
+	})
+	if want := "Deletes a 'video'.\ntrue\n200\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateDescribe: true, DescribeFull: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, strconv.Quote(strings.TrimSpace(long))) {
+		t.Errorf("DescribeFull should return the untruncated description\nGenerated code:\n%s", code)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "Describe()") {
+		t.Error("Describe should only be generated with GenerateDescribe")
+	}
+}
+
+func TestMethodInfoPathTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		method Method
+		want   string
+		flat   string
+	}{
+		{"path", Method{Path: "youtube/v3/videos"}, "youtube/v3/videos", ""},
+		{"reserved expansion", Method{Path: "v1/{+parent}/topics"}, "v1/{+parent}/topics", ""},
+		{"flatPath", Method{Path: "v1/{+parent}/topics", FlatPath: "v1/projects/{projectsId}/topics"}, "v1/{+parent}/topics", "v1/projects/{projectsId}/topics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MethodInfo{Method: &tt.method}
+			if got := m.PathTemplate(); got != tt.want {
+				t.Errorf("PathTemplate() = %q, want %q", got, tt.want)
+			}
+			if got := m.FlatPathTemplate(); got != tt.flat {
+				t.Errorf("FlatPathTemplate() = %q, want %q", got, tt.flat)
+			}
+		})
+	}
+}
+
+func TestGenerateMCPToolsHTTPInfo(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"list":   {ID: "youtube.videos.list", HTTPMethod: "GET", Path: "youtube/v3/videos"},
+				"delete": {ID: "youtube.videos.delete", HTTPMethod: "DELETE", Path: "youtube/v3/videos/{+id}"},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateHTTPInfo: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+type request interface {
+	HTTPMethod() string
+	PathTemplate() string
+}
+
+func main() {
+	for _, r := range []request{APIVideosListArgs{}, &APIVideosDeleteArgs{}} {
+		fmt.Println(r.HTTPMethod(), r.PathTemplate())
+	}
+}
+`,
+	})
+	if want := "GET youtube/v3/videos\nDELETE youtube/v3/videos/{+id}\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "HTTPMethod()") || strings.Contains(code, "PathTemplate()") {
+		t.Error("HTTPMethod and PathTemplate should only be generated with GenerateHTTPInfo")
+	}
+}
+
+func TestGenerateMCPToolsHTTPInfoExpandPath(t *testing.T) {
+	doc := &Document{
+		Name: "pubsub",
+		Resources: map[string]*Resource{
+			"topics": {Methods: map[string]*Method{
+				"list": {
+					ID: "pubsub.projects.topics.list", HTTPMethod: "GET",
+					Path: "v1/{+parent}/topics", FlatPath: "v1/projects/{projectsId}/topics",
+					Parameters: map[string]*Parameter{
+						"parent":   {Type: "string", Location: "path", Required: true},
+						"pageSize": {Type: "integer", Format: "int32", Location: "query"},
+					},
+				},
+				"get": {
+					ID: "pubsub.projects.topics.get", HTTPMethod: "GET", Path: "v1/topics/{topic}",
+					Parameters: map[string]*Parameter{
+						"topic": {Type: "string", Location: "path", Required: true},
+					},
+				},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateHTTPInfo: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+type request interface {
+	PathTemplate() string
+	FlatPathTemplate() string
+}
+
+var placeholder = regexp.MustCompile(` + "`\\{(\\+?)(\\w+)\\}`" + `)
+
+// expand fills the template from the json fields of args, escaping slashes
+// except in reserved expansions.
+func expand(r request) string {
+	raw, _ := json.Marshal(r)
+	var fields map[string]any
+	_ = json.Unmarshal(raw, &fields)
+	return placeholder.ReplaceAllStringFunc(r.PathTemplate(), func(m string) string {
+		sub := placeholder.FindStringSubmatch(m)
+		v := fmt.Sprint(fields[sub[2]])
+		if sub[1] == "+" {
+			return v
+		}
+		return url.PathEscape(v)
+	})
+}
+
+func main() {
+	list := APITopicsListArgs{Parent: "projects/p1"}
+	fmt.Println(expand(list), list.FlatPathTemplate())
+	get := APITopicsGetArgs{Topic: "a/b"}
+	fmt.Printf("%s %q\n", expand(get), get.FlatPathTemplate())
+}
+`,
+	})
+	if want := "v1/projects/p1/topics v1/projects/{projectsId}/topics\nv1/topics/a%2Fb \"\"\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestGenerateMCPToolsOmitToolDefinitions(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"list": {ID: "youtube.videos.list", Description: "List videos", Parameters: map[string]*Parameter{
+					"part": {Type: "string", Location: "query", Required: true},
+				}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", OmitToolDefinitions: true, GenerateAssertions: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "GeneratedToolDefinitions") {
+		t.Errorf("GeneratedToolDefinitions should be omitted\nGenerated code:\n%s", code)
+	}
+	if !containsFieldType(code, "Part", "string") {
+		t.Errorf("args structs should still be generated\nGenerated code:\n%s", code)
+	}
+	runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go":  "package main\n\nfunc main() { _ = APIVideosListArgs{} }\n",
+	})
+}
+
+func TestGenerateMCPToolsMaxFieldDescriptionLen(t *testing.T) {
+	long := "The video's privacy status, which controls who can see it. " + strings.Repeat("Lots more detail. ", 30)
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{
+				"privacyStatus": {Type: "string", Description: long, Enum: []string{"public", "private"}, Default: "private"},
+			}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"insert": {ID: "youtube.videos.insert", Request: &SchemaRef{Ref: "Video"}, Parameters: map[string]*Parameter{
+					"part": {Type: "string", Location: "query", Description: long},
+				}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, MaxFieldDescriptionLen: 40})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		`jsonschema:"The video's privacy status, which..."`,
+		`jsonschema:"The video's privacy status, which... Values: public, private (default: private)"`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "Lots more detail") {
+		t.Errorf("long field descriptions should be truncated\nGenerated code:\n%s", code)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, strings.TrimSpace(long)) {
+		t.Error("field descriptions should not be truncated by default")
+	}
+}
+
+func TestTruncateWords(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"one two three four", 0, "one two three four"},
+		{"one two three four", 12, "one two..."},
+		{"one, two three", 10, "one..."},
+		{"abcdefghijklmnop", 8, "abcde..."},
+	}
+	for _, tt := range tests {
+		if got := truncateWords(tt.text, tt.limit); got != tt.want {
+			t.Errorf("truncateWords(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateMCPToolsURLValues(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"list": {ID: "youtube.videos.list", Parameters: map[string]*Parameter{
+					"channelId":  {Type: "string", Location: "path", Required: true},
+					"part":       {Type: "string", Location: "query", Required: true, Repeated: true},
+					"chart":      {Type: "string", Location: "query", Enum: []string{"mostPopular", "chartUnspecified"}},
+					"maxResults": {Type: "integer", Format: "uint32", Location: "query"},
+					"rating":     {Type: "number", Location: "query"},
+					"mine":       {Type: "boolean", Location: "query"},
+					"hl":         {Type: "string", Location: "query"},
+				}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateURLValues: true, GenerateEnums: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	mine := true
+	args := APIVideosListArgs{
+		ChannelID:  "UC123",
+		Part:       []string{"snippet", "statistics"},
+		Chart:      ChartMostPopular,
+		MaxResults: 5,
+		Rating:     4.5,
+		Mine:       &mine,
+	}
+	fmt.Println(args.ToURLValues().Encode())
+	fmt.Println(APIVideosListArgs{}.ToURLValues().Encode() == "")
+}
+`,
+	})
+	if want := "chart=mostPopular&maxResults=5&mine=true&part=snippet&part=statistics&rating=4.5\ntrue\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateURLValues: true, OptionalAsPointer: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	out = runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	n := uint32(0)
+	fmt.Println(APIVideosListArgs{MaxResults: &n}.ToURLValues().Encode())
+}
+`,
+	})
+	if want := "maxResults=0\n"; out != want {
+		t.Errorf("with OptionalAsPointer a set zero value should be sent, output = %q, want %q", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "ToURLValues") || strings.Contains(code, `"net/url"`) {
+		t.Error("ToURLValues should only be generated with GenerateURLValues")
+	}
+}
+
+func TestGenerateMCPToolsInputSchemaMap(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", InputSchemaMap: true, GenerateEnums: true, GenerateRegistry: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "type APIVideosListArgs struct") {
+		t.Errorf("args structs should be replaced by GeneratedInputSchemas\nGenerated code:\n%s", code)
+	}
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	schema := GeneratedInputSchemas["youtube_videos_list"]
+	fmt.Println(schema["type"], schema["required"])
+	_, ok := schema["properties"].(map[string]any)["maxResults"]
+	fmt.Println(ok, len(GeneratedInputSchemas) == len(GeneratedToolDefinitions))
+}
+`,
+	})
+	if want := "object [part]\ntrue true\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestGenerateMCPToolsSchemaPrefix(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := GenerateOptions{PackageName: "main", GenerateSchema: true, SchemaPrefix: "YT", SplitReadWrite: true, GenerateHandlers: true, MCPImportPath: "gentest/mcp"}
+	code, err := GenerateMCPTools(doc, opts)
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		"type YTVideo struct",
+		"type YTVideoListResponse struct",
+		`ResponseType: "YTVideoListResponse"`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q", want)
+		}
+	}
+	if !containsFieldType(code, "Items", "[]*YTVideo") || !containsFieldType(code, "Snippet", "*YTVideoSnippet") {
+		t.Error("references should use the prefixed type names")
+	}
+	if strings.Contains(code, "type Video struct") || strings.Contains(code, "*Video\n") {
+		t.Error("no unprefixed schema type should remain")
+	}
+	if t.Failed() {
+		t.Logf("Generated code:\n%s", code)
+	}
+	runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go":  "package main\n\nfunc main() { _ = YTVideoListResponse{Items: []*YTVideo{{}}} }\n",
+	})
+
+	for _, prefix := range []string{"1x", "yt"} {
+		opts.SchemaPrefix = prefix
+		if _, err := GenerateMCPTools(doc, opts); err == nil || !strings.Contains(err.Error(), "invalid SchemaPrefix") {
+			t.Errorf("GenerateMCPTools with SchemaPrefix %s = %v, want invalid SchemaPrefix error", prefix, err)
+		}
+	}
+}
+
+func TestGenerateMCPToolsQuotaCost(t *testing.T) {
+	doc, err := Parse([]byte(`{
+  "name": "youtube",
+  "resources": {
+    "videos": {
+      "methods": {
+        "list": {"id": "youtube.videos.list", "quotaCost": 1},
+        "insert": {"id": "youtube.videos.insert", "quotaCost": 1600},
+        "rate": {"id": "youtube.videos.rate"}
+      }
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		"var GeneratedToolQuotaCost = map[string]int{",
+		`"youtube_videos_insert": 1600,`,
+		`"youtube_videos_list":   1,`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	_, quota, _ := strings.Cut(code, "var GeneratedToolQuotaCost")
+	if quota, _, _ = strings.Cut(quota, "\n}"); strings.Contains(quota, "youtube_videos_rate") {
+		t.Errorf("tools without a quota hint should have no GeneratedToolQuotaCost entry\n%s", quota)
+	}
+
+	delete(doc.Resources["videos"].Methods, "list")
+	delete(doc.Resources["videos"].Methods, "insert")
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "var GeneratedToolQuotaCost = map[string]int{}") {
+		t.Errorf("without quota hints GeneratedToolQuotaCost should be empty\nGenerated code:\n%s", code)
+	}
+}
+
+func TestGenerateMCPToolsNoOmitEmpty(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{
+				"id":    {Type: "string", Required: true},
+				"title": {Type: "string"},
+				"tags":  {Type: "array", Items: &Schema{Type: "string"}},
+			}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"insert": {ID: "youtube.videos.insert", Request: &SchemaRef{Ref: "Video"}, Parameters: map[string]*Parameter{
+					"part": {Type: "string", Location: "query"},
+				}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, NoOmitEmpty: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{`json:"id"`, `json:"title"`, `json:"tags"`, `json:"part,omitempty"`} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	for _, unwanted := range []string{`json:"title,omitempty"`, `json:"tags,omitempty"`} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("generated code contains %q with NoOmitEmpty\nGenerated code:\n%s", unwanted, code)
+		}
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, `json:"title,omitempty"`) {
+		t.Errorf("optional fields should keep omitempty by default\nGenerated code:\n%s", code)
+	}
+}
+
+func TestGenerateMCPToolsCheckRequired(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateCheckRequired: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(CheckRequired("youtube_videos_list", map[string]any{"maxResults": 5}))
+	fmt.Println(CheckRequired("youtube_videos_list", map[string]any{"part": nil}))
+	fmt.Println(CheckRequired("youtube_videos_list", map[string]any{"part": []any{"snippet"}}))
+	fmt.Println(CheckRequired("unknown", nil))
+}
+`,
+	})
+	want := "youtube_videos_list: missing required arguments: part\n" +
+		"youtube_videos_list: missing required arguments: part\n" +
+		"<nil>\n<nil>\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "CheckRequired") {
+		t.Error("CheckRequired should only be generated with GenerateCheckRequired")
+	}
+}
+
+func TestGenerateMCPToolsExactImports(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	schemasOnly := &Document{Name: "youtube", Schemas: doc.Schemas}
+
+	tests := []struct {
+		name    string
+		doc     *Document
+		opts    GenerateOptions
+		imports []string // nil: no import block at all
+	}{
+		{"plain", doc, GenerateOptions{}, nil},
+		{"schema", doc, GenerateOptions{GenerateSchema: true}, nil},
+		{"raw any without freeform fields", doc, GenerateOptions{GenerateSchema: true, RawMessageForAny: true}, nil},
+		{"registry without methods", schemasOnly, GenerateOptions{GenerateRegistry: true, RegistryImportPath: "example.com/tools", GenerateSchema: true, AllSchemas: true}, nil},
+		{"marshal json", doc, GenerateOptions{GenerateSchema: true, GenerateMarshalJSON: true}, []string{`"encoding/json"`, `"reflect"`, `"strings"`}},
+		{"json schema without args types", doc, GenerateOptions{GenerateJSONSchema: true, InputSchemaMap: true}, nil},
+		{"aliased mcp import", doc, GenerateOptions{GenerateHandlers: true, MCPImportPath: "example.com/mcp/v2"}, []string{`"context"`, `"encoding/json"`, `mcp "example.com/mcp/v2"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := GenerateMCPTools(tt.doc, tt.opts)
+			if err != nil {
+				t.Fatalf("GenerateMCPTools failed: %v", err)
+			}
+			if tt.imports == nil {
+				if strings.Contains(code, "\nimport") {
+					t.Errorf("generated code should have no import block\nGenerated code:\n%s", code)
+				}
+				return
+			}
+			for _, imp := range tt.imports {
+				if !strings.Contains(code, "\t"+imp+"\n") {
+					t.Errorf("generated code missing import %s\nGenerated code:\n%s", imp, code)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateMCPToolsAllTools(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main"})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+func main() {
+	tools := AllTools()
+	fmt.Println(tools, sort.StringsAreSorted(tools), len(tools) == len(GeneratedToolDefinitions))
+	for _, name := range tools {
+		if _, ok := GeneratedToolDefinitions[name]; !ok {
+			fmt.Println("unknown tool", name)
+		}
+	}
+}
+`,
+	})
+	if want := "[youtube_videos_get youtube_videos_insert youtube_videos_list] true true\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestGenerateMCPToolsRefBySchemaID(t *testing.T) {
+	// The document keys Video as "VideoResource"; $refs name it by its id.
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Schemas: map[string]*Schema{
+			"VideoResource": {ID: "Video", Type: "object", Properties: map[string]*Schema{
+				"title": {Type: "string"},
+			}},
+			"VideoListResponse": {ID: "VideoListResponse", Type: "object", Properties: map[string]*Schema{
+				"items": {Type: "array", Items: &Schema{Ref: "Video"}},
+				"top":   {Ref: "Video"},
+			}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"get":  {ID: "test.videos.get", HTTPMethod: "GET", Path: "videos", Response: &SchemaRef{Ref: "Video"}},
+				"list": {ID: "test.videos.list", HTTPMethod: "GET", Path: "videos", Response: &SchemaRef{Ref: "VideoListResponse"}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "type VideoResource struct") {
+		t.Errorf("schema referenced by id should be generated under its key\nGenerated code:\n%s", code)
+	}
+	if !containsFieldType(code, "Items", "[]*VideoResource") || !containsFieldType(code, "Top", "*VideoResource") {
+		t.Errorf("$ref by id should resolve to VideoResource\nGenerated code:\n%s", code)
+	}
+	if strings.Contains(code, "*Video ") || strings.Contains(code, "*Video\n") {
+		t.Errorf("$ref by id should not produce the undefined type Video\nGenerated code:\n%s", code)
+	}
+	runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go":  "package main\n\nfunc main() { _ = VideoListResponse{Top: &VideoResource{}} }\n",
+	})
+}
+
+func TestGenerateMCPToolsRevision(t *testing.T) {
+	doc, err := Parse([]byte(`{"name": "test", "version": "v1", "revision": "20240115", "etag": "\"abc123\""}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if doc.Revision != "20240115" || doc.Etag != `"abc123"` {
+		t.Fatalf("Revision, Etag = %q, %q", doc.Revision, doc.Etag)
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{`Revision:    "20240115",`, `Etag:        "\"abc123\"",`} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+}
+
+func TestGenerateMCPToolsInputlessMethod(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Resources: map[string]*Resource{
+			"channels": {Methods: map[string]*Method{
+				"mine": {ID: "test.channels.mine", HTTPMethod: "GET", Path: "channels/mine"},
+				"get": {ID: "test.channels.get", HTTPMethod: "GET", Path: "channels/{id}", Parameters: map[string]*Parameter{
+					"id": {Type: "string", Location: "path", Required: true},
+				}},
+				"insert": {ID: "test.channels.insert", HTTPMethod: "POST", Path: "channels", Request: &SchemaRef{Ref: "Channel"}},
+			}},
+		},
+		Schemas: map[string]*Schema{
+			"Channel": {ID: "Channel", Type: "object", Properties: map[string]*Schema{"title": {Type: "string"}}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	want := "type APIChannelsMineArgs struct {\n\t// test_channels_mine takes no input: the method has no parameters and no request body.\n}"
+	if !strings.Contains(code, want) {
+		t.Errorf("expected an empty args struct noting the tool takes no input\nGenerated code:\n%s", code)
+	}
+	if strings.Count(code, "takes no input") != 1 {
+		t.Errorf("only channels.mine takes no input\nGenerated code:\n%s", code)
+	}
+
+	names, err := InputlessMethods(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("InputlessMethods failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"channels.mine"}) {
+		t.Errorf("InputlessMethods = %q, want [channels.mine]", names)
+	}
+	if names, _ := InputlessMethods(doc, GenerateOptions{SchemaOnly: true}); len(names) != 0 {
+		t.Errorf("InputlessMethods with SchemaOnly = %q, want none", names)
+	}
+}
+
+func TestGenerateMCPToolsEscapeMarkdown(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"list": {ID: "test.videos.list", Description: "Lists *emphasis* videos_by_id, see [docs] #1.", Parameters: map[string]*Parameter{
+					"id": {Type: "string", Location: "query"},
+				}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{EscapeMarkdown: true, GenerateHandlers: true, GenerateDescribe: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	escaped := `Lists \*emphasis\* videos\_by\_id, see \[docs\] \#1.`
+	if !strings.Contains(code, "return "+strconv.Quote(escaped)) {
+		t.Errorf("Describe should match the escaped GeneratedToolDefinitions entry\nGenerated code:\n%s", code)
+	}
+	if !strings.Contains(code, `"test_videos_list": `+"`"+escaped+"`") {
+		t.Errorf("GeneratedToolDefinitions should hold the escaped description\nGenerated code:\n%s", code)
+	}
+	if !strings.Contains(code, `register("test_videos_list", `+"`"+escaped+"`") {
+		t.Errorf("registration should use the escaped description\nGenerated code:\n%s", code)
+	}
+	if !strings.Contains(code, "// Lists *emphasis* videos_by_id, see [docs] #1.") {
+		t.Errorf("the Go doc comment should keep the raw description\nGenerated code:\n%s", code)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, `"test_videos_list": `+"`Lists *emphasis* videos_by_id, see [docs] #1.`") {
+		t.Errorf("descriptions should stay raw by default\nGenerated code:\n%s", code)
+	}
+}
+
+func TestGenerateMCPToolsBooleanDefault(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"insert": {ID: "test.videos.insert", Parameters: map[string]*Parameter{
+					"notifySubscribers": {Type: "boolean", Location: "query", Default: "True", Description: "Whether to notify subscribers."},
+					"stabilize":         {Type: "boolean", Location: "query", Default: "false"},
+				}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !containsFieldType(code, "NotifySubscribers", "*bool") {
+		t.Errorf("an optional boolean should be *bool so an explicit false overrides the true default\nGenerated code:\n%s", code)
+	}
+	for _, want := range []string{`jsonschema:"Whether to notify subscribers. (default: true)"`, `jsonschema:" (default: false)"`} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+
+	tests := []struct {
+		def  string
+		want string
+	}{
+		{"true", "true"},
+		{"True", "true"},
+		{" FALSE ", "false"},
+		{"", "true"},
+		{"maybe", "true"},
+	}
+	for _, tt := range tests {
+		if got := exampleScalar("flag", &Parameter{Type: "boolean", Default: tt.def}); got != tt.want {
+			t.Errorf("exampleScalar with default %q = %q, want %q", tt.def, got, tt.want)
+		}
+	}
+	if got := defaultText("string", "True"); got != "True" {
+		t.Errorf("defaultText should leave non-boolean defaults alone, got %q", got)
+	}
+}
+
+func TestGenerateMCPToolsOptionalNumbersAsPointer(t *testing.T) {
+	doc := &Document{
+		Name:    "test",
+		Version: "v1",
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"list": {ID: "test.videos.list", HTTPMethod: "GET", Path: "videos", Response: &SchemaRef{Ref: "Video"}, Parameters: map[string]*Parameter{
+					"offset":     {Type: "integer", Location: "query"},
+					"maxResults": {Type: "integer", Format: "uint32", Location: "query", Required: true},
+					"title":      {Type: "string", Location: "query"},
+				}},
+			}},
+		},
+		Schemas: map[string]*Schema{
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{
+				"rating": {Type: "number", Format: "double"},
+				"tags":   {Type: "array", Items: &Schema{Type: "integer", Format: "int32"}},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateSchema: true, OptionalNumbersAsPointer: true, GenerateURLValues: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for field, want := range map[string]string{
+		"Offset":     "*int64",
+		"MaxResults": "uint32",
+		"Title":      "string",
+		"Rating":     "*float64",
+		"Tags":       "[]int32",
+	} {
+		if !containsFieldType(code, field, want) {
+			t.Errorf("%s: want %s\nGenerated code:\n%s", field, want, code)
+		}
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	zero := int64(0)
+	args := APIVideosListArgs{MaxResults: 5, Offset: &zero}
+	b, _ := json.Marshal(args)
+	fmt.Println(string(b), args.ToURLValues().Encode())
+}
+`,
+	})
+	if want := "{\"maxResults\":5,\"offset\":0} maxResults=5&offset=0\n"; out != want {
+		t.Errorf("an explicit 0 should be sent: got %q, want %q", out, want)
+	}
+}
+
+func TestGenerateMCPToolsDispatch(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateDispatch: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "func (f *ToolFuncs) Dispatch(ctx context.Context, name string, raw json.RawMessage) (any, error) {") {
+		t.Fatalf("expected a Dispatch function\nGenerated code:\n%s", code)
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func main() {
+	funcs := &ToolFuncs{
+		VideosList: func(ctx context.Context, args APIVideosListArgs) (any, error) {
+			return strings.Join(args.Part, "+") + " " + string(args.Chart), nil
+		},
+	}
+	fmt.Println(funcs.Dispatch(context.Background(), "youtube_videos_list", []byte(` + "`" + `{"part": ["id", "snippet"], "chart": "mostPopular"}` + "`" + `)))
+	fmt.Println(funcs.Dispatch(context.Background(), "youtube_videos_list", []byte("[")))
+	fmt.Println(funcs.Dispatch(context.Background(), "youtube_videos_get", nil))
+	fmt.Println(funcs.Dispatch(context.Background(), "youtube_videos_rate", nil))
+}
+`,
+	})
+	want := "id+snippet mostPopular <nil>\n" +
+		"<nil> youtube_videos_list: invalid arguments: unexpected end of JSON input\n" +
+		"<nil> youtube_videos_get is not implemented\n" +
+		"<nil> unknown tool \"youtube_videos_rate\"\n"
+	if out != want {
+		t.Errorf("Dispatch reported:\n%s\nwant:\n%s", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "Dispatch") {
+		t.Error("Dispatch should only be generated with GenerateDispatch")
+	}
+}
+
+func TestGenerateMCPToolsVersionedMCPImport(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	code, err := GenerateMCPTools(doc, GenerateOptions{
+		PackageName:      "main",
+		Methods:          []string{"videos.list"},
+		GenerateHandlers: true,
+		MCPImportPath:    fakeMCPImportPath + "/v2",
+	})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if want := "\tmcp \"" + fakeMCPImportPath + "/v2\"\n"; !strings.Contains(code, want) {
+		t.Errorf("a /v2 MCP import should be aliased to mcp, missing %q\nGenerated code:\n%s", want, code)
+	}
+	runGenerated(t, map[string]string{
+		"tools.go":      code,
+		"mcp/v2/mcp.go": fakeMCPPackage,
+		"main.go": `package main
+
+func main() {
+	RegisterTools(func(name, description string, handler ToolHandler) {})
+}
+`,
+	})
+}
+
+func TestGenerateMCPToolsFieldNamedLikeStruct(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {
+				ID:   "Video",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"video":      {Type: "string"},
+					"videoField": {Type: "string"},
+				},
+			},
+			"Thumbnail": {
+				ID:         "Thumbnail",
+				Type:       "object",
+				Properties: map[string]*Schema{"thumbnail": {Ref: "Thumbnail"}},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateSchema: true, AllSchemas: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for field, typ := range map[string]string{
+		"VideoField2":    "string",
+		"VideoField":     "string",
+		"ThumbnailField": "*Thumbnail",
+	} {
+		if !containsFieldType(code, field, typ) {
+			t.Errorf("expected field %s %s\nGenerated code:\n%s", field, typ, code)
+		}
+	}
+	if !strings.Contains(code, `json:"video,omitempty"`) || !strings.Contains(code, `json:"thumbnail,omitempty"`) {
+		t.Error("renamed fields should keep their json names")
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	data, _ := json.Marshal(Thumbnail{ThumbnailField: &Thumbnail{}})
+	fmt.Println(string(data))
+}
+`,
+	})
+	if want := `{"thumbnail":{}}` + "\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestGenerateMCPToolsToolIO(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {ID: "Video", Type: "object", Properties: map[string]*Schema{
+				"id":    {Type: "string", ReadOnly: true},
+				"title": {Type: "string"},
+			}},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"insert": {ID: "youtube.videos.insert", Request: &SchemaRef{Ref: "Video"}, Response: &SchemaRef{Ref: "Video"}},
+				"delete": {ID: "youtube.videos.delete"},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if want := `"youtube_videos_insert": {RequestType: "Video", ResponseType: "Video", MediaDownload: false},`; !strings.Contains(code, want) {
+		t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+	}
+	if strings.Contains(code, `"youtube_videos_delete": {`) {
+		t.Error("methods without a request or response body should have no GeneratedToolResponses entry")
+	}
+	runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go":  "package main\n\nfunc main() { _ = GeneratedToolResponses[\"youtube_videos_insert\"].RequestType }\n",
+	})
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, SplitReadWrite: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, `"youtube_videos_insert": {RequestType: "VideoRequest", ResponseType: "Video", MediaDownload: false},`) {
+		t.Errorf("with SplitReadWrite the request type should be the request variant\nGenerated code:\n%s", code)
+	}
+
+	delete(doc.Resources["videos"].Methods, "insert")
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "GeneratedToolResponses") {
+		t.Error("GeneratedToolResponses should be omitted when no method has a body")
+	}
+}
+
+func TestGenerateMCPToolsStrictParameterOrder(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{
+				"rate": {
+					ID:             "youtube.videos.rate",
+					ParameterOrder: []string{"id", "rating", "videoId"},
+					Parameters: map[string]*Parameter{
+						"id":     {Type: "string", Location: "query", Required: true},
+						"rating": {Type: "string", Location: "query", Required: true},
+					},
+				},
+				"list": {ID: "youtube.videos.list"},
+			}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("without Strict unknown parameterOrder entries should be ignored, got %v", err)
+	}
+	if !containsFieldType(code, "Rating", "string") || strings.Contains(code, "VideoId") {
+		t.Errorf("only the declared parameters should be generated\n%s", code)
+	}
+	problems, err := DocumentProblems(doc, GenerateOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("DocumentProblems failed: %v", err)
+	}
+	want := `method videos.rate: parameterOrder lists unknown parameter "videoId"`
+	if len(problems) != 1 || problems[0] != want {
+		t.Errorf("DocumentProblems = %q, want [%q]", problems, want)
+	}
+
+	_, err = GenerateMCPTools(doc, GenerateOptions{Strict: true})
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Strict should fail with %q, got %v", want, err)
+	}
+	if _, err := GenerateMCPTools(doc, GenerateOptions{Strict: true, Methods: []string{"videos.list"}}); err != nil {
+		t.Errorf("Strict should only check the selected methods, got %v", err)
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// TestGenerateGolden pins the complete generated output for a fixture document.
+// Run "go test ./discovery -run TestGenerateGolden -update" after intended changes.
+func TestGenerateGolden(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := GenerateOptions{PackageName: "youtube", GenerateSchema: true, GenerateEnums: true}
+
+	code, err := GenerateMCPTools(doc, opts)
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	again, err := GenerateMCPTools(doc, opts)
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if code != again {
+		t.Fatal("regenerating identical input produced different output")
+	}
+	if string(normalizeSource([]byte(code))) != code {
+		t.Error("generated output is not a fixed point of normalizeSource")
+	}
+
+	golden := filepath.Join("testdata", "youtube_v3.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(code), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if code != string(want) {
+		t.Errorf("generated output differs from %s (run with -update if intended)\nGot:\n%s", golden, code)
+	}
+}
+
+func TestNormalizeSource(t *testing.T) {
+	in := "package x\r\n\r\n\r\nfunc f() {\n\n\treturn   \n\n}\n\n\nvar y = 1\n\n"
+	want := "package x\n\nfunc f() {\n\treturn\n}\n\nvar y = 1\n"
+	if got := string(normalizeSource([]byte(in))); got != want {
+		t.Errorf("normalizeSource = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package discovery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// goGenerics is the minor version of the Go release that introduced type
+// parameters and the any alias, along with reflect.Pointer and strings.Cut.
+const goGenerics = 18
+
+// parseGoVersion parses a GoVersion option such as "1.17", "go1.21" or
+// "1.22.3" and returns its minor version. The empty string targets the latest
+// release and yields 0.
+func parseGoVersion(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(v, "go"), "1.")
+	minorStr, _, _ := strings.Cut(rest, ".")
+	minor, err := strconv.Atoi(minorStr)
+	if !ok || err != nil || minor < 0 {
+		return 0, fmt.Errorf("invalid GoVersion %q: want a Go release such as %q", v, "1.21")
+	}
+	return minor, nil
+}
+
+// goVersionAtLeast reports whether the targeted Go release, as returned by
+// parseGoVersion, has the features introduced in Go 1.minor.
+func goVersionAtLeast(target, minor int) bool {
+	return target == 0 || target >= minor
+}
+
+// anyType returns the Go type of values of unknown type: any, or interface{}
+// when opts targets a Go release before 1.18. Every any in generated code is
+// spelled through it.
+func anyType(opts *GenerateOptions) string {
+	if opts != nil {
+		if minor, err := parseGoVersion(opts.GoVersion); err == nil && !goVersionAtLeast(minor, goGenerics) {
+			return "interface{}"
+		}
+	}
+	return "any"
+}
@@ -0,0 +1,92 @@
+package discovery
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGoVersion(t *testing.T) {
+	for v, want := range map[string]int{"": 0, "1.17": 17, "go1.21": 21, "1.22.3": 22} {
+		if got, err := parseGoVersion(v); err != nil || got != want {
+			t.Errorf("parseGoVersion(%q) = %d, %v; want %d", v, got, err, want)
+		}
+	}
+	for _, v := range []string{"17", "2.1", "1.x", "go"} {
+		if _, err := parseGoVersion(v); err == nil {
+			t.Errorf("parseGoVersion(%q) should fail", v)
+		}
+	}
+}
+
+func TestGenerateMCPToolsGoVersion(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	opts := GenerateOptions{
+		PackageName:         "tools",
+		GenerateSchema:      true,
+		GenericListResponse: true,
+		GenerateHandlers:    true,
+		MCPImportPath:       fakeMCPImportPath,
+		GenerateInputSchema: true,
+		GoVersion:           "1.17",
+	}
+	code, err := GenerateMCPTools(doc, opts)
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "map[string]interface{}") || strings.Contains(code, "map[string]any") {
+		t.Errorf("GoVersion 1.17 should spell any as interface{}\nGenerated code:\n%s", code)
+	}
+	if strings.Contains(code, "ListResponse[") {
+		t.Errorf("GoVersion 1.17 should skip generic list responses\nGenerated code:\n%s", code)
+	}
+	// A go 1.17 module rejects any and type parameters.
+	runGo(t, map[string]string{
+		"go.mod":     "module gentest\n\ngo 1.17\n",
+		"mcp/mcp.go": strings.ReplaceAll(fakeMCPPackage, "any", "interface{}"),
+		"tools.go":   code,
+	}, "build", "./...")
+
+	opts.GoVersion = ""
+	if code, err = GenerateMCPTools(doc, opts); err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "map[string]any") || !strings.Contains(code, "ListResponse[") {
+		t.Errorf("the latest Go release should use any and generic list responses\nGenerated code:\n%s", code)
+	}
+
+	opts.GoVersion = "1.17"
+	opts.GenerateMarshalJSON = true
+	if _, err := GenerateMCPTools(doc, opts); err == nil || !strings.Contains(err.Error(), "requires GoVersion 1.18") {
+		t.Errorf("GenerateMarshalJSON with GoVersion 1.17 = %v, want a GoVersion error", err)
+	}
+}
+
+func TestAnyType(t *testing.T) {
+	for _, tt := range []struct {
+		goVersion string
+		want      string
+	}{
+		{"", "any"},
+		{"1.18", "any"},
+		{"1.17", "interface{}"},
+	} {
+		opts := &GenerateOptions{GoVersion: tt.goVersion}
+		if got := scalarGoType("any", "", false, opts); got != tt.want {
+			t.Errorf("GoVersion %q: scalarGoType(any) = %q, want %q", tt.goVersion, got, tt.want)
+		}
+		for property, want := range map[*Schema]string{
+			{Type: "any"}:    tt.want,
+			{Type: "array"}:  "[]" + tt.want,
+			{Type: "object"}: "map[string]" + tt.want,
+		} {
+			p := &PropertyInfo{Name: "x", Property: property, Options: opts}
+			if got := p.GoType(); got != want {
+				t.Errorf("GoVersion %q: GoType of %s property = %q, want %q", tt.goVersion, property.Type, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InputSchema returns the JSON Schema describing the method's arguments, built from
+// the same parameter metadata used for the generated struct tags.
+func (m *MethodInfo) InputSchema() map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	for _, p := range m.SortedParams() {
+		properties[p.WireName()] = paramJSONSchema(p.Param)
+		if p.Required() {
+			required = append(required, p.WireName())
+		}
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// InputSchemaLiteral returns InputSchema rendered as a Go composite literal.
+func (m *MethodInfo) InputSchemaLiteral() string {
+	return goLiteral(m.InputSchema(), anyType(m.Options))
+}
+
+// paramJSONSchema returns the JSON Schema for a single parameter.
+func paramJSONSchema(p *Parameter) map[string]any {
+	schema := make(map[string]any)
+	if p.Type != "" && p.Type != "any" {
+		schema["type"] = p.Type
+	}
+	if len(p.Enum) > 0 {
+		schema["enum"] = p.Enum.ForType(p.Type)
+	}
+	if p.Repeated {
+		schema = map[string]any{"type": "array", "items": schema}
+	}
+	if desc := cleanDescription(p.Description); desc != "" {
+		schema["description"] = desc
+	}
+	return schema
+}
+
+// goLiteral renders a value built from maps, slices, strings, numbers and bools as Go source,
+// spelling the map value type unknown (see anyType). Map keys are sorted so the
+// output is deterministic.
+func goLiteral(v any, unknown string) string {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			return "map[string]" + unknown + "{}"
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteString("map[string]" + unknown + "{\n")
+		for _, k := range keys {
+			b.WriteString(strconv.Quote(k) + ": " + goLiteral(v[k], unknown) + ",\n")
+		}
+		b.WriteString("}")
+		return b.String()
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = strconv.Quote(s)
+		}
+		return "[]string{" + strings.Join(quoted, ", ") + "}"
+	case []any:
+		values := make([]string, len(v))
+		for i, e := range v {
+			values[i] = goLiteral(e, unknown)
+		}
+		return "[]" + unknown + "{" + strings.Join(values, ", ") + "}"
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		panic(fmt.Sprintf("goLiteral: unsupported type %T", v))
+	}
+}
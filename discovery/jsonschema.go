@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// defaultJSONSchemaImportPath is the jsonschema package GenerateJSONSchema targets.
+const defaultJSONSchemaImportPath = "github.com/invopop/jsonschema"
+
+// jsonSchemaImport returns the import spec for the jsonschema package at
+// importPath, aliased to jsonschema when its last element differs.
+func jsonSchemaImport(importPath string) string {
+	if path.Base(importPath) == "jsonschema" {
+		return importPath
+	}
+	return "jsonschema " + importPath
+}
+
+// JSONSchemaLiteral returns the parameter's JSON Schema (see paramJSONSchema)
+// as a *jsonschema.Schema composite literal.
+func (p *ParamInfo) JSONSchemaLiteral() string {
+	return jsonSchemaLiteral(paramJSONSchema(p.Param), anyType(p.Options))
+}
+
+// RequiredArgsLiteral returns RequiredArgs as a []string literal, or "" if the
+// method has no required arguments.
+func (m *MethodInfo) RequiredArgsLiteral() string {
+	required := m.RequiredArgs()
+	if len(required) == 0 {
+		return ""
+	}
+	return goLiteral(required, anyType(m.Options))
+}
+
+// jsonSchemaLiteral renders a schema built by paramJSONSchema as a
+// *jsonschema.Schema literal, spelling enum values' type unknown.
+func jsonSchemaLiteral(schema map[string]any, unknown string) string {
+	var fields []string
+	if t, ok := schema["type"].(string); ok {
+		fields = append(fields, "Type: "+strconv.Quote(t))
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		fields = append(fields, "Items: "+jsonSchemaLiteral(items, unknown))
+	}
+	switch enum := schema["enum"].(type) {
+	case []string:
+		values := make([]string, len(enum))
+		for i, v := range enum {
+			values[i] = strconv.Quote(v)
+		}
+		fields = append(fields, "Enum: []"+unknown+"{"+strings.Join(values, ", ")+"}")
+	case []any:
+		fields = append(fields, "Enum: "+goLiteral(enum, unknown))
+	}
+	if desc, ok := schema["description"].(string); ok {
+		fields = append(fields, "Description: "+strconv.Quote(desc))
+	}
+	return "&jsonschema.Schema{" + strings.Join(fields, ", ") + "}"
+}
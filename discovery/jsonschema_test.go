@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeJSONSchemaPackage mirrors the parts of invopop/jsonschema that generated
+// JSONSchema methods use.
+const fakeJSONSchemaPackage = `package jsonschema
+
+type Schema struct {
+	Type        string
+	Items       *Schema
+	Enum        []any
+	Description string
+	Properties  *Properties
+	Required    []string
+}
+
+type Properties struct {
+	Keys   []string
+	Values map[string]*Schema
+}
+
+func NewProperties() *Properties {
+	return &Properties{Values: make(map[string]*Schema)}
+}
+
+func (p *Properties) Set(key string, value *Schema) {
+	p.Keys = append(p.Keys, key)
+	p.Values[key] = value
+}
+`
+
+func TestGenerateMCPToolsJSONSchema(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{
+		PackageName:          "main",
+		Methods:              []string{"videos.list"},
+		GenerateJSONSchema:   true,
+		JSONSchemaImportPath: "gentest/jsonschema",
+	})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "func (APIVideosListArgs) JSONSchema() *jsonschema.Schema {") {
+		t.Fatalf("expected a JSONSchema method\nGenerated code:\n%s", code)
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go":             code,
+		"jsonschema/schema.go": fakeJSONSchemaPackage,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	s := APIVideosListArgs{}.JSONSchema()
+	fmt.Println(s.Type, s.Required)
+	part := s.Properties.Values["part"]
+	fmt.Println(part.Type, part.Items.Type)
+	fmt.Println(s.Properties.Values["chart"].Enum)
+}
+`,
+	})
+	want := "object [part]\narray string\n[chartUnspecified mostPopular]\n"
+	if out != want {
+		t.Errorf("generated JSONSchema reported:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestJSONSchemaImport(t *testing.T) {
+	if got := jsonSchemaImport(defaultJSONSchemaImportPath); got != defaultJSONSchemaImportPath {
+		t.Errorf("jsonSchemaImport(%q) = %q, want it unaliased", defaultJSONSchemaImportPath, got)
+	}
+	if got, want := jsonSchemaImport("example.com/jsonschema/v2"), "jsonschema example.com/jsonschema/v2"; got != want {
+		t.Errorf("jsonSchemaImport = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,214 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// openAPIVersion is the OpenAPI version GenerateOpenAPI emits.
+const openAPIVersion = "3.0.3"
+
+// GenerateOpenAPI converts a Discovery Document into a minimal OpenAPI 3.0 spec,
+// rendered as indented JSON. Paths and operations come from the methods (honoring
+// opts.Methods, opts.MethodsRegex and opts.IncludeCommonParams), and every schema
+// becomes a component.
+func GenerateOpenAPI(doc *Document, opts GenerateOptions) ([]byte, error) {
+	names, err := filterMethods(opts, doc.SortedMethodNames())
+	if err != nil {
+		return nil, err
+	}
+
+	methods := doc.AllMethods()
+	ids := schemaIDIndex(doc.Schemas)
+	paths := make(map[string]map[string]any)
+	for _, name := range names {
+		m := methods[name]
+		if m.HTTPMethod == "" {
+			return nil, fmt.Errorf("method %s has no httpMethod", name)
+		}
+		path := "/" + strings.ReplaceAll(m.Path, "{+", "{")
+		verb := strings.ToLower(m.HTTPMethod)
+		if paths[path] == nil {
+			paths[path] = make(map[string]any)
+		}
+		if _, dup := paths[path][verb]; dup {
+			return nil, fmt.Errorf("method %s: duplicate operation %s %s", name, m.HTTPMethod, path)
+		}
+		var common map[string]*Parameter
+		if opts.IncludeCommonParams {
+			common = doc.Parameters
+		}
+		paths[path][verb] = openAPIOperation(name, m, common, ids)
+	}
+
+	schemas := make(map[string]any, len(doc.Schemas))
+	for name, s := range doc.Schemas {
+		schemas[name] = openAPISchema(s, ids)
+	}
+
+	info := map[string]any{"title": doc.Title, "version": doc.Version}
+	if info["title"] == "" {
+		info["title"] = doc.Name
+	}
+	if desc := cleanDescription(doc.Description); desc != "" {
+		info["description"] = desc
+	}
+	spec := map[string]any{
+		"openapi":    openAPIVersion,
+		"info":       info,
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+	if doc.RootURL != "" {
+		spec["servers"] = []any{map[string]any{"url": strings.TrimSuffix(doc.RootURL+doc.ServicePath, "/")}}
+	}
+	return json.MarshalIndent(spec, "", "  ")
+}
+
+// openAPIOperation builds the operation object for a single method. ids maps
+// schema IDs to their document keys, as built by schemaIDIndex.
+func openAPIOperation(name string, m *Method, common map[string]*Parameter, ids map[string]string) map[string]any {
+	op := map[string]any{"operationId": name}
+	if m.ID != "" {
+		op["operationId"] = m.ID
+	}
+	if desc := cleanDescription(m.Description); desc != "" {
+		op["description"] = desc
+	}
+
+	var paramNames []string
+	for pname := range common {
+		if _, ok := m.Parameters[pname]; !ok {
+			paramNames = append(paramNames, pname)
+		}
+	}
+	for pname := range m.Parameters {
+		paramNames = append(paramNames, pname)
+	}
+	sort.Strings(paramNames)
+	var params []any
+	for _, pname := range paramNames {
+		p, ok := m.Parameters[pname]
+		if !ok {
+			p = common[pname]
+		}
+		params = append(params, openAPIParameter(pname, p))
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if m.Request != nil && m.Request.Ref != "" {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": openAPIRef(schemaKey(m.Request.Ref, ids))}},
+		}
+	}
+	response := map[string]any{"description": "Successful response"}
+	if m.Response != nil && m.Response.Ref != "" {
+		response["content"] = map[string]any{"application/json": map[string]any{"schema": openAPIRef(schemaKey(m.Response.Ref, ids))}}
+	}
+	op["responses"] = map[string]any{"200": response}
+	if len(m.Scopes) > 0 {
+		op["x-google-scopes"] = m.Scopes
+	}
+	return op
+}
+
+// openAPIParameter builds a parameter object. Path parameters are always required.
+func openAPIParameter(name string, p *Parameter) map[string]any {
+	location := p.Location
+	if location == "" {
+		location = "query"
+	}
+	schema := openAPIScalar(p.Type, p.Format)
+	if len(p.Enum) > 0 {
+		schema["enum"] = p.Enum.ForType(p.Type)
+	}
+	if p.Default != "" {
+		schema["default"] = p.Default
+	}
+	if p.Repeated {
+		schema = map[string]any{"type": "array", "items": schema}
+	}
+	param := map[string]any{
+		"name":   name,
+		"in":     location,
+		"schema": schema,
+	}
+	if p.Required || location == "path" {
+		param["required"] = true
+	}
+	if desc := cleanDescription(p.Description); desc != "" {
+		param["description"] = desc
+	}
+	return param
+}
+
+// openAPISchema converts a Discovery schema into an OpenAPI schema object,
+// resolving references through ids.
+func openAPISchema(s *Schema, ids map[string]string) map[string]any {
+	if s.Ref != "" {
+		return openAPIRef(schemaKey(s.Ref, ids))
+	}
+	var schema map[string]any
+	switch s.Type {
+	case "object":
+		schema = map[string]any{"type": "object"}
+		if len(s.Properties) > 0 {
+			props := make(map[string]any, len(s.Properties))
+			var required []string
+			for name, prop := range s.Properties {
+				props[name] = openAPISchema(prop, ids)
+				if prop.Required {
+					required = append(required, name)
+				}
+			}
+			schema["properties"] = props
+			if len(required) > 0 {
+				sort.Strings(required)
+				schema["required"] = required
+			}
+		}
+		if s.AdditionalProperties != nil {
+			schema["additionalProperties"] = openAPISchema(s.AdditionalProperties, ids)
+		}
+	case "array":
+		schema = map[string]any{"type": "array", "items": map[string]any{}}
+		if s.Items != nil {
+			schema["items"] = openAPISchema(s.Items, ids)
+		}
+	default:
+		schema = openAPIScalar(s.Type, s.Format)
+	}
+	if len(s.Enum) > 0 {
+		schema["enum"] = s.Enum.ForType(s.Type)
+	}
+	if s.ReadOnly {
+		schema["readOnly"] = true
+	}
+	if desc := cleanDescription(s.Description); desc != "" {
+		schema["description"] = desc
+	}
+	return schema
+}
+
+// openAPIScalar returns the schema for a Discovery scalar type. The "any" type
+// (and an empty one) maps to the empty schema.
+func openAPIScalar(typ, format string) map[string]any {
+	schema := make(map[string]any)
+	if typ != "" && typ != "any" {
+		schema["type"] = typ
+	}
+	if format != "" {
+		schema["format"] = format
+	}
+	return schema
+}
+
+// openAPIRef returns a reference to a component schema.
+func openAPIRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
@@ -0,0 +1,108 @@
+package discovery
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateOpenAPI(t *testing.T) {
+	doc, err := LoadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := GenerateOpenAPI(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateOpenAPI failed: %v", err)
+	}
+	var spec struct {
+		OpenAPI string                               `json:"openapi"`
+		Servers []struct{ URL string }               `json:"servers"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+		Comps   struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("generated spec is not valid JSON: %v\n%s", err, data)
+	}
+
+	if spec.OpenAPI != openAPIVersion {
+		t.Errorf("openapi = %q, want %q", spec.OpenAPI, openAPIVersion)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != "https://youtube.googleapis.com" {
+		t.Errorf("servers = %v, want https://youtube.googleapis.com", spec.Servers)
+	}
+
+	list, ok := spec.Paths["/youtube/v3/videos"]["get"]
+	if !ok {
+		t.Fatalf("missing GET /youtube/v3/videos for videos.list:\n%s", data)
+	}
+	if list["operationId"] != "youtube.videos.list" {
+		t.Errorf("operationId = %v, want youtube.videos.list", list["operationId"])
+	}
+	if _, ok := spec.Paths["/youtube/v3/videos"]["post"]; !ok {
+		t.Error("missing POST /youtube/v3/videos for videos.insert")
+	}
+	get, ok := spec.Paths["/youtube/v3/videos/{videoId}"]["get"]
+	if !ok {
+		t.Fatal("missing GET /youtube/v3/videos/{videoId} for videos.get")
+	}
+	params := get["parameters"].([]any)
+	if p := params[0].(map[string]any); p["in"] != "path" || p["required"] != true {
+		t.Errorf("videoId parameter = %v, want a required path parameter", p)
+	}
+
+	for _, name := range []string{"Video", "VideoListResponse", "VideoSnippet"} {
+		if _, ok := spec.Comps.Schemas[name]; !ok {
+			t.Errorf("missing component schema %s", name)
+		}
+	}
+
+	data, err = GenerateOpenAPI(doc, GenerateOptions{Methods: []string{"videos.get"}})
+	if err != nil {
+		t.Fatalf("GenerateOpenAPI failed: %v", err)
+	}
+	spec.Paths = nil
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Paths) != 1 {
+		t.Errorf("got %d paths with Methods filter, want 1", len(spec.Paths))
+	}
+}
+
+func TestGenerateOpenAPIRefByID(t *testing.T) {
+	doc, err := Parse([]byte(`{
+	"name": "youtube",
+	"resources": {"videos": {"methods": {"get": {
+		"id": "youtube.videos.get", "httpMethod": "GET", "path": "videos",
+		"response": {"$ref": "Video"}
+	}}}},
+	"schemas": {
+		"VideoResource": {"id": "Video", "type": "object", "properties": {"snippet": {"$ref": "Snippet"}}},
+		"SnippetResource": {"id": "Snippet", "type": "object", "properties": {"title": {"type": "string"}}},
+		"Unused": {"id": "Unused", "type": "object"}
+	}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := GenerateOpenAPI(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateOpenAPI failed: %v", err)
+	}
+	for _, want := range []string{
+		`"$ref": "#/components/schemas/VideoResource"`,
+		`"$ref": "#/components/schemas/SnippetResource"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("spec missing %s:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), `"#/components/schemas/Video"`) {
+		t.Errorf("refs by ID should point at the component key:\n%s", data)
+	}
+}
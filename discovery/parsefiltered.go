@@ -0,0 +1,293 @@
+package discovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+)
+
+// ParseFiltered parses a Discovery Document from r, keeping only the methods
+// whose flattened names (e.g. "videos.list") are in keepMethods, which may hold
+// glob patterns like those of GenerateOptions.Methods. The document is decoded
+// as a stream: unselected methods are skipped without being decoded, resources
+// left without methods are dropped, and only the schemas the kept methods
+// reference (directly or transitively) are decoded. For very large documents
+// such as Compute's this keeps peak memory far below Parse.
+//
+// The result generates the same code as Parse for the kept methods, except that
+// GenerateOptions.AllSchemas only sees the referenced schemas. An empty
+// keepMethods keeps everything and is equivalent to Parse.
+func ParseFiltered(r io.Reader, keepMethods []string) (*Document, error) {
+	if len(keepMethods) == 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read discovery document: %w", err)
+		}
+		return Parse(data)
+	}
+	p := &filteredParser{dec: json.NewDecoder(r), keep: keepMethods}
+	doc, err := p.document()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse discovery document: %w", err)
+	}
+	return doc, nil
+}
+
+// filteredParser holds the state of a ParseFiltered run.
+type filteredParser struct {
+	dec  *json.Decoder
+	keep []string
+}
+
+// document decodes the top-level object. Everything but methods, resources and
+// schemas is small and decoded as usual once the stream is consumed.
+func (p *filteredParser) document() (*Document, error) {
+	doc := &Document{}
+	rest := make(map[string]json.RawMessage)
+	var rawSchemas map[string]json.RawMessage
+	err := p.object(func(key string) error {
+		var err error
+		switch key {
+		case "resources":
+			doc.Resources, err = p.resources("")
+		case "methods":
+			doc.Methods, err = p.methods("")
+		case "schemas":
+			err = p.dec.Decode(&rawSchemas)
+		default:
+			var value json.RawMessage
+			err = p.dec.Decode(&value)
+			rest[key] = value
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the document")
+	}
+
+	restJSON, err := json.Marshal(rest)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(restJSON, doc); err != nil {
+		return nil, err
+	}
+	doc.Schemas, err = referencedSchemas(doc, rawSchemas)
+	return doc, err
+}
+
+// resources decodes a resources object, dropping resources without kept methods.
+func (p *filteredParser) resources(prefix string) (map[string]*Resource, error) {
+	resources := make(map[string]*Resource)
+	err := p.object(func(name string) error {
+		r, err := p.resource(joinMethodName(prefix, name))
+		if r != nil {
+			resources[name] = r
+		}
+		return err
+	})
+	if len(resources) == 0 {
+		resources = nil
+	}
+	return resources, err
+}
+
+// resource decodes a single resource, or returns nil if it keeps no methods.
+func (p *filteredParser) resource(prefix string) (*Resource, error) {
+	r := &Resource{}
+	err := p.object(func(key string) error {
+		var err error
+		switch key {
+		case "methods":
+			r.Methods, err = p.methods(prefix)
+		case "resources":
+			r.Resources, err = p.resources(prefix)
+		default:
+			err = skipValue(p.dec)
+		}
+		return err
+	})
+	if err != nil || (r.Methods == nil && r.Resources == nil) {
+		return nil, err
+	}
+	return r, nil
+}
+
+// methods decodes the kept methods of a methods object and skips the others.
+func (p *filteredParser) methods(prefix string) (map[string]*Method, error) {
+	methods := make(map[string]*Method)
+	err := p.object(func(name string) error {
+		if !p.keeps(joinMethodName(prefix, name)) {
+			return skipValue(p.dec)
+		}
+		var m Method
+		if err := p.dec.Decode(&m); err != nil {
+			return fmt.Errorf("method %s: %w", joinMethodName(prefix, name), err)
+		}
+		methods[name] = &m
+		return nil
+	})
+	if len(methods) == 0 {
+		methods = nil
+	}
+	return methods, err
+}
+
+// keeps reports whether the flattened method name is selected.
+func (p *filteredParser) keeps(name string) bool {
+	for _, pattern := range p.keep {
+		if pattern == name {
+			return true
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// object consumes a JSON object (or null), calling fn with each key. fn must
+// consume the key's value.
+func (p *filteredParser) object(fn func(key string) error) error {
+	tok, err := p.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected object, got %v", tok)
+	}
+	for p.dec.More() {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected object key %v", tok)
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	_, err = p.dec.Token() // closing brace
+	return err
+}
+
+// skipValue consumes the next JSON value without keeping it.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// joinMethodName joins a resource path and a name the way AllMethods flattens them.
+func joinMethodName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// referencedSchemas decodes the raw schemas the document's methods reference,
+// following references between schemas. A reference matching no key falls back
+// to the schema with that ID, like schemaKey.
+func referencedSchemas(doc *Document, raw map[string]json.RawMessage) (map[string]*Schema, error) {
+	schemas := make(map[string]*Schema)
+	var ids map[string]string
+	var visit func(name string) error
+	visit = func(name string) error {
+		if _, ok := raw[name]; !ok {
+			if ids == nil {
+				var err error
+				if ids, err = rawSchemaIDIndex(raw); err != nil {
+					return err
+				}
+			}
+			name = schemaKey(name, ids)
+		}
+		data, ok := raw[name]
+		if _, done := schemas[name]; done || !ok {
+			return nil
+		}
+		var s Schema
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+		schemas[name] = &s
+		return visitSchemaRefs(&s, visit)
+	}
+	for _, m := range doc.AllMethods() {
+		for _, ref := range []*SchemaRef{m.Request, m.Response} {
+			if ref == nil || ref.Ref == "" {
+				continue
+			}
+			if err := visit(ref.Ref); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(schemas) == 0 {
+		return nil, nil
+	}
+	return schemas, nil
+}
+
+// rawSchemaIDIndex is schemaIDIndex for undecoded schemas, decoding only their IDs.
+func rawSchemaIDIndex(raw map[string]json.RawMessage) (map[string]string, error) {
+	ids := make(map[string]string)
+	for key, data := range raw {
+		var s struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", key, err)
+		}
+		if _, isKey := raw[s.ID]; s.ID != "" && !isKey {
+			ids[s.ID] = key
+		}
+	}
+	return ids, nil
+}
+
+// visitSchemaRefs calls visit with every reference in schema and its nested schemas.
+func visitSchemaRefs(schema *Schema, visit func(name string) error) error {
+	if schema.Ref != "" {
+		if err := visit(schema.Ref); err != nil {
+			return err
+		}
+	}
+	for _, prop := range schema.Properties {
+		if err := visitSchemaRefs(prop, visit); err != nil {
+			return err
+		}
+	}
+	for _, nested := range []*Schema{schema.Items, schema.AdditionalProperties} {
+		if nested != nil {
+			if err := visitSchemaRefs(nested, visit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseFiltered(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "youtube_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := ParseFiltered(bytes.NewReader(data), []string{"videos.list"})
+	if err != nil {
+		t.Fatalf("ParseFiltered failed: %v", err)
+	}
+	if got := doc.SortedMethodNames(); strings.Join(got, ",") != "videos.list" {
+		t.Errorf("methods = %v, want [videos.list]", got)
+	}
+	if !reflect.DeepEqual(doc.AllMethods()["videos.list"], full.AllMethods()["videos.list"]) {
+		t.Error("the kept method should decode exactly as with Parse")
+	}
+	if doc.Name != full.Name || doc.RootURL != full.RootURL || !reflect.DeepEqual(doc.Parameters, full.Parameters) {
+		t.Error("document fields other than methods should be kept")
+	}
+	if _, ok := doc.Schemas["VideoListResponse"]; !ok {
+		t.Errorf("referenced schemas should be kept, got %v", doc.Schemas)
+	}
+
+	// Filtered parsing generates the same code as filtering at generation time.
+	opts := GenerateOptions{Methods: []string{"videos.*"}, GenerateSchema: true}
+	doc, err = ParseFiltered(bytes.NewReader(data), opts.Methods)
+	if err != nil {
+		t.Fatalf("ParseFiltered failed: %v", err)
+	}
+	want, err := GenerateMCPTools(full, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := GenerateMCPTools(doc, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("code from ParseFiltered differs from Parse\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := ParseFiltered(strings.NewReader(`{"resources": [}`), []string{"videos.list"}); err == nil {
+		t.Error("malformed JSON should fail")
+	}
+}
+
+func TestParseFilteredRefByID(t *testing.T) {
+	doc, err := ParseFiltered(strings.NewReader(`{
+	"name": "youtube",
+	"resources": {"videos": {"methods": {"get": {
+		"id": "youtube.videos.get", "httpMethod": "GET", "path": "videos",
+		"response": {"$ref": "Video"}
+	}}}},
+	"schemas": {
+		"VideoResource": {"id": "Video", "type": "object", "properties": {"snippet": {"$ref": "Snippet"}}},
+		"SnippetResource": {"id": "Snippet", "type": "object", "properties": {"title": {"type": "string"}}},
+		"Unused": {"id": "Unused", "type": "object"}
+	}
+}`), []string{"videos.get"})
+	if err != nil {
+		t.Fatalf("ParseFiltered failed: %v", err)
+	}
+	var got []string
+	for name := range doc.Schemas {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	if want := "SnippetResource,VideoResource"; strings.Join(got, ",") != want {
+		t.Errorf("schemas = %v, want %s", got, want)
+	}
+}
+
+// largeDocument returns a synthetic document with many resources, methods and
+// schemas, approximating the size of Compute's.
+func largeDocument(b *testing.B) []byte {
+	b.Helper()
+	doc := &Document{Name: "big", Schemas: make(map[string]*Schema), Resources: make(map[string]*Resource)}
+	for i := range 300 {
+		name := fmt.Sprintf("Thing%d", i)
+		props := make(map[string]*Schema)
+		for j := range 40 {
+			props[fmt.Sprintf("field%d", j)] = &Schema{Type: "string", Description: strings.Repeat("Long description. ", 10)}
+		}
+		doc.Schemas[name] = &Schema{ID: name, Type: "object", Properties: props}
+		methods := make(map[string]*Method)
+		for _, verb := range []string{"get", "list", "insert", "delete", "patch"} {
+			params := make(map[string]*Parameter)
+			for j := range 20 {
+				params[fmt.Sprintf("param%d", j)] = &Parameter{Type: "string", Location: "query", Description: strings.Repeat("Parameter detail. ", 10)}
+			}
+			methods[verb] = &Method{ID: "big." + verb, Parameters: params, Response: &SchemaRef{Ref: name}}
+		}
+		doc.Resources[fmt.Sprintf("things%d", i)] = &Resource{Methods: methods}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func BenchmarkParse(b *testing.B) {
+	data := largeDocument(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := Parse(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseFiltered(b *testing.B) {
+	data := largeDocument(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := ParseFiltered(bytes.NewReader(data), []string{"things7.list"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ScopeInfo describes a generated OAuth scope constant.
+type ScopeInfo struct {
+	ConstName string // e.g. "ScopeYoutubeUpload"
+	URL       string // e.g. "https://www.googleapis.com/auth/youtube.upload"
+}
+
+// collectScopes returns one ScopeInfo per distinct scope used by the methods,
+// sorted by URL, with unique constant names.
+func collectScopes(methods []*MethodInfo) []*ScopeInfo {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, m := range methods {
+		for _, s := range m.Method.Scopes {
+			if !seen[s] {
+				seen[s] = true
+				urls = append(urls, s)
+			}
+		}
+	}
+	sort.Strings(urls)
+
+	scopes := make([]*ScopeInfo, 0, len(urls))
+	used := make(map[string]bool)
+	for _, u := range urls {
+		base := scopeConstName(u)
+		name := base
+		for i := 2; used[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		used[name] = true
+		scopes = append(scopes, &ScopeInfo{ConstName: name, URL: u})
+	}
+	return scopes
+}
+
+// scopeConstName derives a constant name from a scope URL. Google scopes under
+// /auth/ are named after the rest of the path ("https://www.googleapis.com/auth/youtube.upload"
+// becomes "ScopeYoutubeUpload"); other URLs use host and path.
+func scopeConstName(scope string) string {
+	name := scope
+	if u, err := url.Parse(scope); err == nil && u.Host != "" {
+		name = u.Host + u.Path
+		if _, rest, ok := strings.Cut(u.Path, "/auth/"); ok && rest != "" {
+			name = rest
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("Scope")
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// ScopeConst returns the constant name generated for a scope URL.
+func (d *TemplateData) ScopeConst(scope string) string {
+	for _, s := range d.Scopes {
+		if s.URL == scope {
+			return s.ConstName
+		}
+	}
+	return strconv.Quote(scope)
+}
@@ -0,0 +1,130 @@
+package discovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScopeConstName(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  string
+	}{
+		{"https://www.googleapis.com/auth/youtube.upload", "ScopeYoutubeUpload"},
+		{"https://www.googleapis.com/auth/youtube", "ScopeYoutube"},
+		{"https://www.googleapis.com/auth/youtube.force-ssl", "ScopeYoutubeForceSsl"},
+		{"https://www.googleapis.com/auth/cloud-platform.read-only", "ScopeCloudPlatformReadOnly"},
+		{"https://mail.google.com/", "ScopeMailGoogleCom"},
+		{"openid", "ScopeOpenid"},
+	}
+	for _, tt := range tests {
+		if got := scopeConstName(tt.scope); got != tt.want {
+			t.Errorf("scopeConstName(%q) = %q, want %q", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateMCPToolsScopes(t *testing.T) {
+	const (
+		youtube = "https://www.googleapis.com/auth/youtube"
+		upload  = "https://www.googleapis.com/auth/youtube.upload"
+	)
+	doc := &Document{
+		Name: "youtube",
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"insert": {ID: "youtube.videos.insert", Scopes: []string{youtube, upload}},
+					"list":   {ID: "youtube.videos.list", Scopes: []string{youtube}},
+					"rate":   {ID: "youtube.videos.rate"},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateScopes: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		`ScopeYoutubeUpload = "https://www.googleapis.com/auth/youtube.upload"`,
+		`"youtube_videos_insert": {ScopeYoutube, ScopeYoutubeUpload},`,
+		`"youtube_videos_list":   {ScopeYoutube},`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\nGenerated code:\n%s", want, code)
+		}
+	}
+	if n := strings.Count(code, `ScopeYoutube       = `); n != 1 {
+		t.Errorf("ScopeYoutube should be declared once, got %d", n)
+	}
+
+	files, err := GenerateFiles(doc, GenerateOptions{GenerateScopes: true})
+	if err != nil {
+		t.Fatalf("GenerateFiles failed: %v", err)
+	}
+	if !strings.Contains(files["scopes.go"], "ScopeYoutubeUpload") {
+		t.Errorf("scopes.go should hold the scope constants, got:\n%s", files["scopes.go"])
+	}
+	if strings.Contains(files["tools.go"], "ScopeYoutubeUpload =") {
+		t.Error("tools.go should not redeclare the scope constants")
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "GeneratedToolScopes") {
+		t.Error("scopes should only be generated with GenerateScopes")
+	}
+}
+
+func TestGenerateMCPToolsScopesMethod(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Resources: map[string]*Resource{
+			"videos": {
+				Methods: map[string]*Method{
+					"insert": {
+						ID:     "youtube.videos.insert",
+						Scopes: []string{"https://www.googleapis.com/auth/youtube.upload", "https://www.googleapis.com/auth/youtube"},
+					},
+					"rate": {ID: "youtube.videos.rate"},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{PackageName: "main", GenerateScopes: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if !strings.Contains(code, "func (APIVideosInsertArgs) Scopes() []string {") {
+		t.Fatalf("Scopes method should be generated\nGenerated code:\n%s", code)
+	}
+
+	out := runGenerated(t, map[string]string{
+		"tools.go": code,
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(APIVideosInsertArgs{}.Scopes())
+	fmt.Println(APIVideosRateArgs{}.Scopes() == nil)
+}
+`,
+	})
+	want := "[https://www.googleapis.com/auth/youtube.upload https://www.googleapis.com/auth/youtube]\ntrue\n"
+	if out != want {
+		t.Errorf("Scopes() output = %q, want %q", out, want)
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "Scopes() []string") {
+		t.Error("Scopes methods should only be generated with GenerateScopes")
+	}
+}
@@ -0,0 +1,109 @@
+package discovery
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ReexportData is the data for the re-export file GenerateFiles writes into the
+// parent package when GenerateOptions.Subpackage is set.
+type ReexportData struct {
+	*TemplateData
+	Parent     string   // Package name of the parent, e.g. "youtube"
+	ImportSpec string   // Import of the subpackage, aliased if its path does not end in its name
+	Subpackage string   // Package name of the subpackage, e.g. "generated"
+	Types      []string // Exported non-generic types, re-exported as aliases
+	Consts     []string // Exported constants
+	Vars       []string // Exported variables and non-generic functions
+}
+
+// checkSubpackage validates a Subpackage directory: a relative, clean, slash-separated
+// path whose last element is a valid package name.
+func checkSubpackage(dir, importPath string) error {
+	if dir != path.Clean(dir) || path.IsAbs(dir) || strings.HasPrefix(dir, "..") || strings.Contains(dir, `\`) {
+		return fmt.Errorf("invalid subpackage %q: must be a clean relative path such as %q", dir, "generated")
+	}
+	if importPath == "" {
+		return fmt.Errorf("subpackage %q requires a SubpackageImport", dir)
+	}
+	return validatePackageName(path.Base(dir))
+}
+
+// subpackageFiles moves the generated files into the subpackage directory and adds
+// the re-export file, named after the subpackage, to the parent package. It carries
+// the same build constraints, so the parent only sees the subpackage when it is built.
+func subpackageFiles(data *TemplateData, opts GenerateOptions, parent string, files map[string]string) (map[string]string, error) {
+	reexport := &ReexportData{
+		TemplateData: data,
+		Parent:       parent,
+		Subpackage:   data.PackageName,
+		ImportSpec:   fmt.Sprintf("%q", opts.SubpackageImport),
+	}
+	if path.Base(opts.SubpackageImport) != data.PackageName {
+		reexport.ImportSpec = data.PackageName + " " + reexport.ImportSpec
+	}
+
+	moved := make(map[string]string, len(files)+1)
+	for name, content := range files {
+		moved[path.Join(opts.Subpackage, name)] = content
+		if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			if err := reexport.collect(name, content); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(reexport.Types)
+	sort.Strings(reexport.Consts)
+	sort.Strings(reexport.Vars)
+
+	code, err := renderTemplate("reexport", reexport)
+	if err != nil {
+		return nil, fmt.Errorf("%s.go: %w", data.PackageName, err)
+	}
+	moved[data.PackageName+".go"] = code
+	return moved, nil
+}
+
+// collect records the exported top-level declarations of a generated file.
+// Generic types and functions cannot be re-exported without instantiating them
+// and are left out.
+func (r *ReexportData) collect(name, src string) error {
+	file, err := parser.ParseFile(token.NewFileSet(), name, src, parser.SkipObjectResolution)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Type.TypeParams == nil && decl.Name.IsExported() {
+				r.Vars = append(r.Vars, decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.TypeParams == nil && spec.Name.IsExported() {
+						r.Types = append(r.Types, spec.Name.Name)
+					}
+				case *ast.ValueSpec:
+					for _, ident := range spec.Names {
+						if !ident.IsExported() {
+							continue
+						}
+						if decl.Tok == token.CONST {
+							r.Consts = append(r.Consts, ident.Name)
+						} else {
+							r.Vars = append(r.Vars, ident.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
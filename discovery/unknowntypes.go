@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UnknownType is a Discovery type/format combination the generator has no Go
+// mapping for. Such values are generated as any (or the format's base type).
+type UnknownType struct {
+	Type   string
+	Format string
+	Uses   []string // Parameters ("videos.list.chart") and properties ("Video.snippet") using it, sorted
+}
+
+// String describes the combination and where it is used.
+func (u UnknownType) String() string {
+	desc := fmt.Sprintf("type %q", u.Type)
+	if u.Format != "" {
+		desc += fmt.Sprintf(" format %q", u.Format)
+	}
+	return desc + " used by " + strings.Join(u.Uses, ", ")
+}
+
+// UnknownTypes reports every distinct type/format combination without a Go
+// mapping among the parameters and schemas GenerateMCPTools would generate for
+// the same options, sorted by type and format.
+func UnknownTypes(doc *Document, opts GenerateOptions) ([]UnknownType, error) {
+	data, err := newTemplateData(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[[2]string]map[string]bool)
+	record := func(typ, format, use string) {
+		if knownType(typ, format) {
+			return
+		}
+		key := [2]string{typ, format}
+		if found[key] == nil {
+			found[key] = make(map[string]bool)
+		}
+		found[key][use] = true
+	}
+	if !data.SchemaOnly {
+		for _, m := range data.Methods {
+			for _, p := range m.SortedParams() {
+				record(p.Param.Type, p.Param.Format, m.FullName+"."+p.Name)
+			}
+		}
+	}
+	for _, s := range data.SchemasToGen {
+		if !s.Request {
+			walkUnknownTypes(s.Name, s.Schema, record)
+		}
+	}
+
+	unknown := make([]UnknownType, 0, len(found))
+	for key, uses := range found {
+		u := UnknownType{Type: key[0], Format: key[1]}
+		for use := range uses {
+			u.Uses = append(u.Uses, use)
+		}
+		sort.Strings(u.Uses)
+		unknown = append(unknown, u)
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		if unknown[i].Type != unknown[j].Type {
+			return unknown[i].Type < unknown[j].Type
+		}
+		return unknown[i].Format < unknown[j].Format
+	})
+	return unknown, nil
+}
+
+// walkUnknownTypes records the type of schema and of everything nested in it.
+// References are not followed; the referenced schemas are walked on their own.
+func walkUnknownTypes(path string, schema *Schema, record func(typ, format, use string)) {
+	if schema.Ref != "" {
+		return
+	}
+	record(schema.Type, schema.Format, path)
+	for name, prop := range schema.Properties {
+		walkUnknownTypes(path+"."+name, prop, record)
+	}
+	if schema.Items != nil {
+		walkUnknownTypes(path+"[]", schema.Items, record)
+	}
+	if schema.AdditionalProperties != nil {
+		walkUnknownTypes(path+"{}", schema.AdditionalProperties, record)
+	}
+}
+
+// knownType reports whether scalarGoType (or the array/object handling around
+// it) has a deliberate mapping for the combination, rather than its fallback
+// for unknown formats or types. An empty type is treated as known: it is how
+// Discovery writes untyped values.
+func knownType(typ, format string) bool {
+	switch typ {
+	case "", "any", "object", "array":
+		return true
+	case "integer":
+		_, ok := integerGoTypes[format]
+		return ok
+	case "number":
+		_, ok := numberGoTypes[format]
+		return ok
+	default:
+		return scalarGoType(typ, format, false, nil) != anyType(nil)
+	}
+}
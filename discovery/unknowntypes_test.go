@@ -0,0 +1,57 @@
+package discovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnknownTypes(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {
+				ID:   "Video",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":       {Type: "string", Format: "google-duration"},
+					"duration": {Type: "interval"},
+					"rating":   {Type: "number", Format: "decimal"},
+					"tags":     {Type: "array", Items: &Schema{Type: "interval"}},
+				},
+			},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{"list": {
+				Response: &SchemaRef{Ref: "Video"},
+				Parameters: map[string]*Parameter{
+					"since": {Type: "interval", Location: "query"},
+					"part":  {Type: "string", Location: "query"},
+				},
+			}}},
+		},
+	}
+
+	unknown, err := UnknownTypes(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("UnknownTypes failed: %v", err)
+	}
+	var got []string
+	for _, u := range unknown {
+		got = append(got, u.String())
+	}
+	want := []string{
+		`type "interval" used by Video.duration, Video.tags[], videos.list.since`,
+		`type "number" format "decimal" used by Video.rating`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("UnknownTypes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	unknown, err = UnknownTypes(doc, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("UnknownTypes failed: %v", err)
+	}
+	if len(unknown) != 1 || len(unknown[0].Uses) != 1 || unknown[0].Uses[0] != "videos.list.since" {
+		t.Errorf("without GenerateSchema only parameters should be checked, got %v", unknown)
+	}
+}
@@ -0,0 +1,80 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// URLValuesStmt returns the statements ToURLValues uses to add the parameter to
+// the url.Values q of receiver a: one value if it is set (non-nil or non-zero),
+// or one value per element of a repeated parameter. Empty for parameters that
+// are not in the query string.
+func (p *ParamInfo) URLValuesStmt() string {
+	if p.Param.Location != "query" {
+		return ""
+	}
+	field := "a." + p.FieldName()
+	goType := p.GoType()
+	elem := strings.TrimPrefix(strings.TrimPrefix(goType, "[]"), "*")
+	name := fmt.Sprintf("%q", p.Name)
+	switch {
+	case strings.HasPrefix(goType, "[]"):
+		return fmt.Sprintf("for _, v := range %s {\nq.Add(%s, %s)\n}", field, name, p.urlFormat(elem, "v"))
+	case strings.HasPrefix(goType, "*"):
+		return fmt.Sprintf("if %s != nil {\nq.Set(%s, %s)\n}", field, name, p.urlFormat(elem, "*"+field))
+	case goType == "bool":
+		return fmt.Sprintf("if %s {\nq.Set(%s, \"true\")\n}", field, name)
+	case goType == anyType(p.Options):
+		return fmt.Sprintf("if %s != nil {\nq.Set(%s, %s)\n}", field, name, p.urlFormat(elem, field))
+	case goType == "string" || p.isEnum():
+		return fmt.Sprintf("if %s != \"\" {\nq.Set(%s, %s)\n}", field, name, p.urlFormat(elem, field))
+	default:
+		return fmt.Sprintf("if %s != 0 {\nq.Set(%s, %s)\n}", field, name, p.urlFormat(elem, field))
+	}
+}
+
+// isEnum reports whether the parameter uses a generated enum type.
+func (p *ParamInfo) isEnum() bool {
+	return p.Enums.typeFor(p.Param.Type, p.Param.Enum) != ""
+}
+
+// urlFormat returns the expression formatting expr, of Go type elem, as a query
+// string value.
+func (p *ParamInfo) urlFormat(elem, expr string) string {
+	switch elem {
+	case "string":
+		return expr
+	case "int32", "int64":
+		return "strconv.FormatInt(int64(" + expr + "), 10)"
+	case "uint32", "uint64":
+		return "strconv.FormatUint(uint64(" + expr + "), 10)"
+	case "float32":
+		return "strconv.FormatFloat(float64(" + expr + "), 'g', -1, 32)"
+	case "float64":
+		return "strconv.FormatFloat(" + expr + ", 'g', -1, 64)"
+	case "bool":
+		return "strconv.FormatBool(" + expr + ")"
+	case anyType(p.Options):
+		return "fmt.Sprint(" + expr + ")"
+	default: // Enum types are strings
+		return "string(" + expr + ")"
+	}
+}
+
+// urlValuesImports returns the packages the ToURLValues methods of methods need.
+func urlValuesImports(methods []*MethodInfo) []string {
+	imports := []string{"net/url"}
+	seen := make(map[string]bool)
+	for _, m := range methods {
+		for _, p := range m.SortedParams() {
+			stmt := p.URLValuesStmt()
+			for _, pkg := range []string{"fmt", "strconv"} {
+				if !seen[pkg] && strings.Contains(stmt, pkg+".") {
+					seen[pkg] = true
+					imports = append(imports, pkg)
+				}
+			}
+		}
+	}
+	return imports
+}
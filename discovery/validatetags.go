@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ValidateTag returns the go-playground/validator rules for the parameter:
+// required, oneof for enums and min/max for numeric bounds. Repeated parameters
+// apply the enum and bounds to each element. Empty if there is nothing to check.
+func (p *ParamInfo) ValidateTag() string {
+	var bounds []string
+	if p.Param.Type == "integer" || p.Param.Type == "number" {
+		if p.Param.Minimum != "" {
+			bounds = append(bounds, "min="+p.Param.Minimum)
+		}
+		if p.Param.Maximum != "" {
+			bounds = append(bounds, "max="+p.Param.Maximum)
+		}
+	}
+	return validateRules(p.GoType(), p.Required(), nil, append(bounds, oneofRule(p.Param.Enum)...))
+}
+
+// ValidateTag returns the go-playground/validator rules for the property:
+// required, oneof for enums and min/max for length, item and property count
+// limits. Empty if there is nothing to check.
+func (p *PropertyInfo) ValidateTag() string {
+	var bounds []string
+	for _, b := range [][2]json.Number{
+		{p.Property.MinLength, p.Property.MaxLength},
+		{p.Property.MinItems, p.Property.MaxItems},
+		{p.Property.MinProperties, p.Property.MaxProperties},
+	} {
+		if b[0] != "" {
+			bounds = append(bounds, "min="+b[0].String())
+		}
+		if b[1] != "" {
+			bounds = append(bounds, "max="+b[1].String())
+		}
+	}
+	return validateRules(p.GoType(), p.Required, bounds, oneofRule(p.enumValues()))
+}
+
+// validateRules assembles a validate tag. outer rules apply to the field itself
+// and elem rules to its value, or to each element (after "dive") of a slice.
+// Required booleans get no required rule, since validator would reject false.
+func validateRules(goType string, required bool, outer, elem []string) string {
+	var rules []string
+	switch {
+	case !required:
+		rules = append(rules, "omitempty")
+	case goType != "bool":
+		rules = append(rules, "required")
+	}
+	rules = append(rules, outer...)
+	if len(elem) > 0 && strings.HasPrefix(goType, "[]") {
+		rules = append(rules, "dive")
+	}
+	rules = append(rules, elem...)
+	if len(rules) == 1 && rules[0] == "omitempty" {
+		return ""
+	}
+	return strings.Join(rules, ",")
+}
+
+// oneofRule returns the oneof rule for an enum, or nothing if there are no
+// values or one of them cannot be written into a struct tag. Values with spaces
+// are single-quoted; commas and pipes use validator's hex escapes.
+func oneofRule(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		if v == "" || strings.ContainsAny(v, "`\"\\'") {
+			return nil
+		}
+		v = strings.NewReplacer(",", "0x2C", "|", "0x7C").Replace(v)
+		if strings.Contains(v, " ") {
+			v = "'" + v + "'"
+		}
+		quoted[i] = v
+	}
+	return []string{"oneof=" + strings.Join(quoted, " ")}
+}
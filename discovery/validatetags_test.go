@@ -0,0 +1,94 @@
+package discovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateMCPToolsValidateTags(t *testing.T) {
+	doc := &Document{
+		Name: "youtube",
+		Schemas: map[string]*Schema{
+			"Video": {
+				ID:   "Video",
+				Type: "object",
+				Properties: map[string]*Schema{
+					"title":   {Type: "string", Required: true, MaxLength: "100"},
+					"tags":    {Type: "array", MaxItems: "500", Items: &Schema{Type: "string", Enum: EnumValues{"music", "news"}}},
+					"private": {Type: "boolean", Required: true},
+					"notes":   {Type: "string"},
+				},
+			},
+		},
+		Resources: map[string]*Resource{
+			"videos": {Methods: map[string]*Method{"list": {
+				ID:       "youtube.videos.list",
+				Response: &SchemaRef{Ref: "Video"},
+				Parameters: map[string]*Parameter{
+					"chart":      {Type: "string", Location: "query", Required: true, Enum: EnumValues{"chartUnspecified", "mostPopular"}},
+					"maxResults": {Type: "integer", Format: "uint32", Location: "query", Minimum: "0", Maximum: "50"},
+					"regions":    {Type: "string", Location: "query", Repeated: true, Enum: EnumValues{"US", "United Kingdom", "a,b"}},
+					"pageToken":  {Type: "string", Location: "query"},
+				},
+			}}},
+		},
+	}
+
+	code, err := GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true, ValidateTags: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	for _, want := range []string{
+		`json:"chart" jsonschema:"Values: chartUnspecified, mostPopular" validate:"required,oneof=chartUnspecified mostPopular"`,
+		`validate:"omitempty,min=0,max=50"`,
+		`validate:"omitempty,dive,oneof=US 'United Kingdom' a0x2Cb"`,
+		`json:"title" jsonschema:"maxLength=100" validate:"required,max=100"`,
+		`validate:"omitempty,max=500,dive,oneof=music news"`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %s\nGenerated code:\n%s", want, code)
+		}
+	}
+	// A required bool is not validated as required: validator would reject false.
+	for _, field := range []string{"pageToken", "notes", "private"} {
+		if hasValidateTag(code, field) {
+			t.Errorf("%s has nothing to validate and should get no validate tag", field)
+		}
+	}
+
+	code, err = GenerateMCPTools(doc, GenerateOptions{GenerateSchema: true})
+	if err != nil {
+		t.Fatalf("GenerateMCPTools failed: %v", err)
+	}
+	if strings.Contains(code, "validate:") {
+		t.Error("validate tags should only be generated with ValidateTags")
+	}
+}
+
+// hasValidateTag reports whether the field with the given json name has a validate tag.
+func hasValidateTag(code, jsonName string) bool {
+	for _, line := range strings.Split(code, "\n") {
+		if strings.Contains(line, `json:"`+jsonName+`"`) || strings.Contains(line, `json:"`+jsonName+`,`) {
+			return strings.Contains(line, "validate:")
+		}
+	}
+	return false
+}
+
+func TestOneofRule(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   []string
+	}{
+		{nil, nil},
+		{[]string{"a", "b"}, []string{"oneof=a b"}},
+		{[]string{"a b", "c|d"}, []string{"oneof='a b' c0x7Cd"}},
+		{[]string{"ok", `say "hi"`}, nil},
+		{[]string{"", "x"}, nil},
+	}
+	for _, tt := range tests {
+		if got := oneofRule(tt.values); strings.Join(got, ";") != strings.Join(tt.want, ";") {
+			t.Errorf("oneofRule(%q) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
@@ -2,139 +2,988 @@
 //
 // Usage:
 //
-//	google-discovery-mcp -api youtube -version v3                    # Fetch from Google
-//	google-discovery-mcp -file youtube-v3.json                       # Use local file
-//	google-discovery-mcp -api youtube -version v3 -methods videos.list,videos.insert
-//	google-discovery-mcp -api youtube -version v3 -schema            # Include schema types
-//	google-discovery-mcp -list                                       # List all Google APIs
+//	google-discovery-mcp generate -api youtube -version v3           # Fetch from Google
+//	google-discovery-mcp generate -file youtube-v3.json              # Use local file
+//	google-discovery-mcp generate -api youtube -version v3 -methods videos.list,videos.insert
+//	google-discovery-mcp generate -api youtube -version v3 -methods 'videos.*' -methods-file methods.txt
+//	google-discovery-mcp generate -api youtube -version v3 -methods-regex '^videos\.(list|insert)$'
+//	google-discovery-mcp generate -api youtube -version v3 -schema   # Include schema types
+//	google-discovery-mcp generate -api youtube -version v3 -output ./youtube/   # Write doc.go + tools.go
+//	google-discovery-mcp generate -file youtube-v3.json -output tools.go -replace-region   # Keep hand-written code
+//	google-discovery-mcp list                                        # List all Google APIs
+//	google-discovery-mcp list -grouped                               # One line per API, all versions
+//	google-discovery-mcp list -hide-deprecated                       # Leave out deprecated API versions
+//	google-discovery-mcp list -list-file directory.json              # List APIs from a saved directory
+//	google-discovery-mcp list-methods -api youtube -version v3       # List methods of an API
+//	google-discovery-mcp diff youtube-old.json youtube-new.json      # Summarize API changes
+//	google-discovery-mcp openapi -api youtube -version v3            # Convert to an OpenAPI 3 spec
+//
+// Invocations without a subcommand (e.g. "google-discovery-mcp -api youtube -version v3",
+// "-list", "-list-methods", "-diff") are still accepted for compatibility.
 //
 // The tool generates Go structs with jsonschema tags suitable for MCP servers.
 // Use -schema to also generate types for request/response body schemas.
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/birdayz/google-discovery-mcp/discovery"
 )
 
+// commands maps subcommand verbs to their implementations. Each parses its own
+// flags from args.
+var commands = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"generate":     runGenerate,
+	"list":         runList,
+	"list-methods": runListMethods,
+	"diff":         runDiff,
+	"openapi":      runOpenAPI,
+}
+
 func main() {
-	var (
-		apiName        = flag.String("api", "", "API name (e.g., youtube, drive, gmail)")
-		version        = flag.String("version", "", "API version (e.g., v3, v1)")
-		file           = flag.String("file", "", "Path to local Discovery Document JSON file")
-		methods        = flag.String("methods", "", "Comma-separated list of methods to generate (default: all)")
-		pkg            = flag.String("package", "tools", "Go package name for generated code")
-		prefix         = flag.String("prefix", "", "Tool name prefix (default: {api}_)")
-		structPrefix   = flag.String("struct-prefix", "API", "Struct name prefix (default: API)")
-		output         = flag.String("output", "", "Output file (default: stdout)")
-		listAPIs       = flag.Bool("list", false, "List all available Google APIs")
-		listMethods    = flag.Bool("list-methods", false, "List all methods in the API")
-		generateSchema = flag.Bool("schema", false, "Generate schema types (request/response bodies)")
-	)
-	flag.Parse()
-
-	if *listAPIs {
-		if err := doListAPIs(); err != nil {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
 		}
-		return
+		os.Exit(1)
+	}
+}
+
+// run dispatches to the subcommand named by args[0]. Without a known verb the
+// arguments are parsed as the legacy flat flag set.
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd(args[1:], stdout, stderr)
+		}
 	}
+	return runLegacy(args, stdout, stderr)
+}
+
+// sourceFlags select the Discovery Document to load.
+type sourceFlags struct {
+	apiName  string
+	version  string
+	file     string
+	format   string
+	quiet    bool
+	printURL bool
+	noCache  bool
+}
 
-	// Load document
+func (f *sourceFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.apiName, "api", "", "API name (e.g., youtube, drive, gmail)")
+	fs.StringVar(&f.version, "version", "", "API version (e.g., v3, v1; default: the preferred version)")
+	fs.StringVar(&f.file, "file", "", "Path to local Discovery Document JSON file")
+	fs.StringVar(&f.format, "format", discovery.DefaultFormat, "Discovery format to fetch with -api (rest or rpc)")
+	fs.BoolVar(&f.quiet, "quiet", false, "Suppress informational output on stderr (errors are still printed)")
+	fs.BoolVar(&f.noCache, "no-cache", false, "Don't read or write the cached API directory used to resolve the preferred version")
+	fs.BoolVar(&f.printURL, "print-url", false, "Print the discovery URL for -api/-version/-format and exit without fetching")
+}
+
+// writeURL prints the URL load would fetch from, for -print-url.
+func (f *sourceFlags) writeURL(w io.Writer) error {
+	if f.apiName == "" || f.version == "" {
+		return errors.New("-print-url requires -api and -version")
+	}
+	url, err := discovery.DiscoveryFormatURL(f.apiName, f.version, f.format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, url)
+	return nil
+}
+
+// load reads the document from -file or fetches it from -api/-version.
+func (f *sourceFlags) load(log *statusLogger) (*discovery.Document, error) {
 	var doc *discovery.Document
 	var err error
-
 	switch {
-	case *file != "":
-		doc, err = discovery.LoadFile(*file)
-	case *apiName != "" && *version != "":
-		fmt.Fprintf(os.Stderr, "Fetching %s %s from googleapis.com...\n", *apiName, *version)
-		doc, err = discovery.Fetch(*apiName, *version)
+	case f.file != "":
+		doc, err = discovery.LoadFile(f.file)
+	case f.apiName != "":
+		if f.version == "" {
+			configureCache(f.noCache)
+			if f.version, err = discovery.PreferredVersion(f.apiName); err != nil {
+				return nil, fmt.Errorf("resolving version: %w", err)
+			}
+			log.logf("Using preferred version %s of %s\n", f.version, f.apiName)
+		}
+		log.logf("Fetching %s %s from googleapis.com...\n", f.apiName, f.version)
+		doc, err = discovery.FetchFormat(f.apiName, f.version, f.format)
 	default:
-		fmt.Fprintf(os.Stderr, "Usage: google-discovery-mcp -api NAME -version VERSION\n")
-		fmt.Fprintf(os.Stderr, "       google-discovery-mcp -file PATH\n")
-		fmt.Fprintf(os.Stderr, "       google-discovery-mcp -list\n\n")
-		flag.PrintDefaults()
-		os.Exit(1)
+		return nil, errors.New("either -file or -api is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading document: %w", err)
 	}
+	log.logf("Loaded: %s (%s)\n", doc.Title, doc.ID)
+	return doc, nil
+}
 
+// generateFlags configure code generation.
+type generateFlags struct {
+	methods        string
+	methodsRegex   string
+	methodsFile    string
+	pkg            string
+	prefix         string
+	prefixTitle    bool
+	structPrefix   string
+	output         string
+	generateSchema bool
+	examples       bool
+	commonParams   bool
+	inputSchema    bool
+	enums          bool
+	preserveOrder  bool
+	buildTags      string
+	handlers       bool
+	dispatch       bool
+	mcpImport      string
+	jsonSchema     bool
+	jsonSchemaPath string
+	noSchemaTags   bool
+	separator      string
+	optionalPtr    bool
+	numberPtr      bool
+	registry       bool
+	registryImport string
+	scopes         bool
+	marshalJSON    bool
+	assertions     bool
+	rawMessage     bool
+	tests          bool
+	schemaOnly     bool
+	allSchemas     bool
+	rootSchemas    string
+	genericLists   bool
+	jsonCase       string
+	fieldComments  bool
+	aliasDups      bool
+	byteAsBytes    bool
+	fieldMask      bool
+	enumValues     bool
+	unknownTypes   bool
+	toolsJSON      bool
+	schemaFile     bool
+	describe       bool
+	describeFull   bool
+	escapeMarkdown bool
+	validateTags   bool
+	subpackage     string
+	subpackageImp  string
+	strict         bool
+	httpInfo       bool
+	noDefinitions  bool
+	maxFieldDesc   int
+	urlValues      bool
+	schemaMap      bool
+	schemaPrefix   string
+	noOmitEmpty    bool
+	checkRequired  bool
+	goVersion      string
+	replaceRegion  bool
+}
+
+func (f *generateFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.methods, "methods", "", "Comma-separated list of methods or globs to generate (default: all)")
+	fs.StringVar(&f.methodsRegex, "methods-regex", "", "Regular expression method names must match, e.g. '^videos\\.(list|insert)$' (combined with -methods)")
+	fs.StringVar(&f.methodsFile, "methods-file", "", "File listing methods or globs to generate, one per line (# comments allowed)")
+	fs.StringVar(&f.pkg, "package", "tools", "Go package name for generated code (with a directory -output, a path uses its last element)")
+	fs.StringVar(&f.prefix, "prefix", "", "Tool name prefix (default: {api}_)")
+	fs.BoolVar(&f.prefixTitle, "prefix-from-title", false, "Derive the default tool name prefix from the API title (e.g. youtube_data_api_)")
+	fs.StringVar(&f.structPrefix, "struct-prefix", "API", "Struct name prefix (default: API)")
+	fs.StringVar(&f.output, "output", "", "Output file, or directory (existing or ending in /) for multi-file output (default: stdout)")
+	fs.BoolVar(&f.replaceRegion, "replace-region", false, "Replace only the code between the "+regionBegin+" and "+regionEnd+" lines of the existing -output file")
+	fs.BoolVar(&f.generateSchema, "schema", false, "Generate schema types (request/response bodies)")
+	fs.StringVar(&f.schemaPrefix, "schema-prefix", "", "Prefix for every generated schema type name, e.g. YT for YTVideo")
+	fs.BoolVar(&f.allSchemas, "all-schemas", false, "With -schema, generate every schema in the document, not only referenced ones")
+	fs.StringVar(&f.rootSchemas, "root-schema", "", "Comma-separated schemas to generate with everything they reference, ignoring methods (implies -schema-only)")
+	fs.BoolVar(&f.genericLists, "generic-lists", false, "Build {items, nextPageToken} list responses on a generic ListResponse[T], embedding it when they have more fields")
+	fs.BoolVar(&f.aliasDups, "alias-duplicates", false, "With -schema, emit schemas identical to an earlier one as type aliases")
+	fs.BoolVar(&f.schemaOnly, "schema-only", false, "Generate only schema types, without tool args or tool definitions")
+	fs.BoolVar(&f.examples, "examples", false, "Emit example literal comments above args structs")
+	fs.BoolVar(&f.commonParams, "common-params", false, "Include document-level parameters (alt, fields, key, ...) in every args struct")
+	fs.BoolVar(&f.describe, "describe", false, "Generate a Describe() method on each args struct returning the tool description")
+	fs.BoolVar(&f.describeFull, "describe-full", false, "With -describe, return the full description instead of the truncated one")
+	fs.BoolVar(&f.escapeMarkdown, "escape-markdown", false, "Escape Markdown syntax in the tool descriptions registered with MCP hosts")
+	fs.BoolVar(&f.urlValues, "url-values", false, "Generate a ToURLValues() method on each args struct encoding its query parameters")
+	fs.BoolVar(&f.checkRequired, "check-required", false, "Generate CheckRequired, reporting required arguments missing from a raw argument map")
+	fs.BoolVar(&f.httpInfo, "http-info", false, "Generate HTTPMethod(), PathTemplate() and FlatPathTemplate() methods on each args struct")
+	fs.BoolVar(&f.inputSchema, "input-schema", false, "Generate an InputSchema() method on each args struct")
+	fs.BoolVar(&f.schemaMap, "input-schema-map", false, "Generate a GeneratedInputSchemas map of each tool's JSON Schema instead of args structs")
+	fs.BoolVar(&f.enums, "enums", false, "Generate shared string enum types and constants")
+	fs.BoolVar(&f.enumValues, "enum-values", false, "Generate a GeneratedEnumValues map of the allowed values of every enum field")
+	fs.BoolVar(&f.preserveOrder, "preserve-order", false, "Emit parameters and properties in document order")
+	fs.StringVar(&f.goVersion, "go-version", "", "Oldest Go release the generated code must build with, e.g. 1.17 (default: latest)")
+	fs.StringVar(&f.buildTags, "tags", "", "Comma-separated build constraints to add to generated files (e.g. integration,!windows)")
+	fs.BoolVar(&f.handlers, "handlers", false, "Generate handler stubs and RegisterTools")
+	fs.BoolVar(&f.dispatch, "dispatch", false, "Generate ToolFuncs with a Dispatch method calling each tool's function with its decoded arguments")
+	fs.StringVar(&f.mcpImport, "mcp-import", "", "Import path of the MCP types package used by handlers (default: github.com/mark3labs/mcp-go/mcp)")
+	fs.BoolVar(&f.jsonSchema, "jsonschema", false, "Generate a JSONSchema() method on each args struct for invopop/jsonschema, built without reflection")
+	fs.StringVar(&f.jsonSchemaPath, "jsonschema-import", "", "Import path of the jsonschema package used by -jsonschema (default: github.com/invopop/jsonschema)")
+	fs.StringVar(&f.jsonCase, "json-case", discovery.JSONTagCaseNone, "Rename json tags: none (Google wire names), snake or camel; anything but none breaks wire compatibility")
+	fs.IntVar(&f.maxFieldDesc, "max-field-description", 0, "Truncate parameter and property descriptions in jsonschema tags to this many bytes (default: no limit)")
+	fs.BoolVar(&f.fieldComments, "field-comments", false, "Also emit each field's description as a comment above the field")
+	fs.BoolVar(&f.unknownTypes, "emit-unknown-types", false, "Warn on stderr about every Discovery type/format without a Go mapping (generated as any)")
+	fs.BoolVar(&f.strict, "strict", false, "Fail on malformed documents (e.g. parameterOrder naming unknown parameters) instead of warning")
+	fs.BoolVar(&f.validateTags, "validate-tags", false, "Add go-playground/validator validate tags (required, oneof, min/max)")
+	fs.BoolVar(&f.noDefinitions, "no-tool-definitions", false, "Skip the GeneratedToolDefinitions description map")
+	fs.BoolVar(&f.noOmitEmpty, "no-omitempty", false, "Drop omitempty from optional schema fields, so zero values are always sent")
+	fs.BoolVar(&f.noSchemaTags, "no-schema-tags", false, "Emit only json struct tags, without jsonschema descriptions")
+	fs.StringVar(&f.separator, "separator", "", "Separator between resource levels in tool names (default: _)")
+	fs.BoolVar(&f.optionalPtr, "optional-pointers", false, "Make every optional scalar field a pointer")
+	fs.BoolVar(&f.numberPtr, "optional-number-pointers", false, "Make optional integer and number fields pointers, so an explicit 0 is sent")
+	fs.BoolVar(&f.registry, "registry", false, "Generate an init() registering every tool into DefaultRegistry, defined in the output (registry.go with a directory -output)")
+	fs.StringVar(&f.registryImport, "registry-import", "", "Import path of a package providing DefaultRegistry, shared by several generated packages")
+	fs.BoolVar(&f.marshalJSON, "marshal-json", false, "Generate MarshalJSON on schema types that omits nil pointers and zero-value structs")
+	fs.BoolVar(&f.fieldMask, "field-mask", false, "Generate FieldMask() on request bodies of update methods, listing the fields that are set")
+	fs.BoolVar(&f.assertions, "assertions", false, "Generate a compile-time check that references every tool's args type")
+	fs.BoolVar(&f.byteAsBytes, "bytes", false, "Use []byte for base64-encoded (format byte) schema properties")
+	fs.BoolVar(&f.rawMessage, "raw-any", false, "Use json.RawMessage for freeform (any, inline object) schema properties")
+	fs.BoolVar(&f.tests, "tests", false, "Also write a _test.go file round-tripping every generated struct through JSON (requires -output)")
+	fs.BoolVar(&f.toolsJSON, "tools-json", false, "Also write tools.json next to the output, mapping tool names to args structs, HTTP methods and paths (requires -output)")
+	fs.BoolVar(&f.schemaFile, "separate-schema-file", false, "With a directory -output, write the schema and enum types to schemas.go instead of tools.go")
+	fs.StringVar(&f.subpackage, "subpackage", "", "With a directory -output, write the code into this subpackage and re-export it from -package")
+	fs.StringVar(&f.subpackageImp, "subpackage-import", "", "Import path of -subpackage (default: a path-like -package followed by the subpackage)")
+	fs.BoolVar(&f.scopes, "scopes", false, "Generate OAuth scope constants, a per-tool scope map and Scopes() methods (scopes.go with directory -output)")
+}
+
+// options converts the flags into generator options.
+func (f *generateFlags) options() (discovery.GenerateOptions, error) {
+	pkg := f.pkg
+	if strings.Contains(pkg, "/") && isDirOutput(f.output) {
+		// A path-like -package ("internal/youtube") names the package after its last element.
+		pkg = path.Base(pkg)
+	}
+	opts := discovery.GenerateOptions{
+		PackageName:              pkg,
+		Prefix:                   f.prefix,
+		PrefixFromTitle:          f.prefixTitle,
+		StructPrefix:             f.structPrefix,
+		SchemaPrefix:             f.schemaPrefix,
+		GenerateSchema:           f.generateSchema,
+		GenerateExamples:         f.examples,
+		IncludeCommonParams:      f.commonParams,
+		GenerateInputSchema:      f.inputSchema,
+		GenerateEnums:            f.enums,
+		PreserveOrder:            f.preserveOrder,
+		GenerateHandlers:         f.handlers,
+		GenerateDispatch:         f.dispatch,
+		MCPImportPath:            f.mcpImport,
+		GenerateJSONSchema:       f.jsonSchema,
+		JSONSchemaImportPath:     f.jsonSchemaPath,
+		OmitSchemaTags:           f.noSchemaTags,
+		ResourceSeparator:        f.separator,
+		OptionalAsPointer:        f.optionalPtr,
+		OptionalNumbersAsPointer: f.numberPtr,
+		GenerateRegistry:         f.registry || f.registryImport != "",
+		RegistryImportPath:       f.registryImport,
+		GenerateScopes:           f.scopes,
+		GenerateMarshalJSON:      f.marshalJSON,
+		GenerateAssertions:       f.assertions,
+		RawMessageForAny:         f.rawMessage,
+		GenerateTests:            f.tests,
+		SchemaOnly:               f.schemaOnly,
+		AllSchemas:               f.allSchemas,
+		GenericListResponse:      f.genericLists,
+		JSONTagCase:              f.jsonCase,
+		FieldComments:            f.fieldComments,
+		AliasDuplicates:          f.aliasDups,
+		ByteAsBytes:              f.byteAsBytes,
+		GenerateFieldMask:        f.fieldMask,
+		GenerateEnumValues:       f.enumValues,
+		ToolsJSON:                f.toolsJSON,
+		SeparateSchemaFile:       f.schemaFile,
+		GenerateDescribe:         f.describe || f.describeFull,
+		DescribeFull:             f.describeFull,
+		EscapeMarkdown:           f.escapeMarkdown,
+		ValidateTags:             f.validateTags,
+		Subpackage:               f.subpackage,
+		SubpackageImport:         f.subpackageImp,
+		Strict:                   f.strict,
+		GenerateHTTPInfo:         f.httpInfo,
+		OmitToolDefinitions:      f.noDefinitions,
+		MaxFieldDescriptionLen:   f.maxFieldDesc,
+		GenerateURLValues:        f.urlValues,
+		InputSchemaMap:           f.schemaMap,
+		NoOmitEmpty:              f.noOmitEmpty,
+		GenerateCheckRequired:    f.checkRequired,
+		GoVersion:                f.goVersion,
+	}
+	if opts.Subpackage != "" && opts.SubpackageImport == "" && strings.Contains(f.pkg, "/") {
+		opts.SubpackageImport = path.Join(f.pkg, opts.Subpackage)
+	}
+	opts.MethodsRegex = f.methodsRegex
+	if f.methods != "" {
+		opts.Methods = strings.Split(f.methods, ",")
+	}
+	if f.methodsFile != "" {
+		fileMethods, err := readMethodsFile(f.methodsFile)
+		if err != nil {
+			return opts, fmt.Errorf("reading methods file: %w", err)
+		}
+		opts.Methods = append(opts.Methods, fileMethods...)
+	}
+	if f.buildTags != "" {
+		opts.BuildTags = strings.Split(f.buildTags, ",")
+	}
+	if f.rootSchemas != "" {
+		opts.RootSchemas = strings.Split(f.rootSchemas, ",")
+	}
+	return opts, nil
+}
+
+// newFlagSet returns a flag set for a subcommand whose usage line is usage.
+func newFlagSet(name, usage string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: google-discovery-mcp %s\n\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runGenerate(args []string, stdout, stderr io.Writer) error {
+	var src sourceFlags
+	var gen generateFlags
+	fs := newFlagSet("generate", "generate (-api NAME [-version VERSION] | -file PATH) [flags]", stderr)
+	src.register(fs)
+	gen.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if src.printURL {
+		return src.writeURL(stdout)
+	}
+	if err := checkMethodsRegex(gen.methodsRegex); err != nil {
+		return err
+	}
+	log := newStatusLogger(stderr, src.quiet)
+	doc, err := src.load(log)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading document: %v\n", err)
-		os.Exit(1)
+		return err
 	}
+	return doGenerate(doc, &gen, stdout, log)
+}
 
-	fmt.Fprintf(os.Stderr, "Loaded: %s (%s)\n", doc.Title, doc.ID)
+func runList(args []string, stdout, stderr io.Writer) error {
+	var quiet, grouped, noCache, hideDeprecated bool
+	var listFile string
+	fs := newFlagSet("list", "list [flags]", stderr)
+	fs.BoolVar(&quiet, "quiet", false, "Suppress informational output on stderr (errors are still printed)")
+	fs.BoolVar(&grouped, "grouped", false, "Print one line per API with all of its versions")
+	fs.BoolVar(&hideDeprecated, "hide-deprecated", false, "Leave out API versions the directory marks as deprecated")
+	fs.BoolVar(&noCache, "no-cache", false, "Fetch the API directory instead of using the cached copy")
+	fs.StringVar(&listFile, "list-file", "", "Read the API directory from a saved JSON file instead of fetching it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	configureCache(noCache)
+	return doListAPIs(stdout, newStatusLogger(stderr, quiet), grouped, hideDeprecated, listFile)
+}
 
-	// List methods mode
-	if *listMethods {
-		fmt.Printf("Methods in %s:\n\n", doc.Name)
-		for _, name := range doc.SortedMethodNames() {
-			m := doc.AllMethods()[name]
-			desc := m.Description
-			if len(desc) > 80 {
-				desc = desc[:77] + "..."
-			}
-			desc = strings.ReplaceAll(desc, "\n", " ")
-			fmt.Printf("  %-40s %s\n", name, desc)
+func runListMethods(args []string, stdout, stderr io.Writer) error {
+	var src sourceFlags
+	fs := newFlagSet("list-methods", "list-methods (-api NAME [-version VERSION] | -file PATH)", stderr)
+	src.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if src.printURL {
+		return src.writeURL(stdout)
+	}
+	doc, err := src.load(newStatusLogger(stderr, src.quiet))
+	if err != nil {
+		return err
+	}
+	doListMethods(stdout, doc)
+	return nil
+}
+
+func runDiff(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("diff", "diff OLD.json NEW.json", stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return doDiff(stdout, fs.Args())
+}
+
+func runOpenAPI(args []string, stdout, stderr io.Writer) error {
+	var src sourceFlags
+	var methods, methodsRegex, output string
+	fs := newFlagSet("openapi", "openapi (-api NAME [-version VERSION] | -file PATH) [flags]", stderr)
+	src.register(fs)
+	fs.StringVar(&methods, "methods", "", "Comma-separated list of methods or globs to include (default: all)")
+	fs.StringVar(&methodsRegex, "methods-regex", "", "Regular expression method names must match (combined with -methods)")
+	fs.StringVar(&output, "output", "", "Output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if src.printURL {
+		return src.writeURL(stdout)
+	}
+	if err := checkMethodsRegex(methodsRegex); err != nil {
+		return err
+	}
+	log := newStatusLogger(stderr, src.quiet)
+	doc, err := src.load(log)
+	if err != nil {
+		return err
+	}
+	opts := discovery.GenerateOptions{MethodsRegex: methodsRegex}
+	if methods != "" {
+		opts.Methods = strings.Split(methods, ",")
+	}
+	spec, err := discovery.GenerateOpenAPI(doc, opts)
+	if err != nil {
+		return fmt.Errorf("generating OpenAPI spec: %w", err)
+	}
+	spec = append(spec, '\n')
+	if output == "" {
+		_, err := stdout.Write(spec)
+		return err
+	}
+	if _, err := writeIfChanged(output, spec); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	log.logf("Generated OpenAPI spec written to %s\n", output)
+	return nil
+}
+
+// runLegacy handles the flat, verb-less flag interface (-list, -list-methods,
+// -diff, or generation). It is kept for compatibility; prefer the subcommands.
+func runLegacy(args []string, stdout, stderr io.Writer) error {
+	var src sourceFlags
+	var gen generateFlags
+	var listAPIs, listMethods, diff, grouped, hideDeprecated bool
+	fs := flag.NewFlagSet("google-discovery-mcp", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	src.register(fs)
+	gen.register(fs)
+	fs.BoolVar(&listAPIs, "list", false, "List all available Google APIs")
+	fs.BoolVar(&grouped, "grouped", false, "With -list, print one line per API with all of its versions")
+	fs.BoolVar(&hideDeprecated, "hide-deprecated", false, "With -list, leave out API versions the directory marks as deprecated")
+	fs.BoolVar(&listMethods, "list-methods", false, "List all methods in the API")
+	fs.BoolVar(&diff, "diff", false, "Compare two local Discovery Documents: -diff OLD.json NEW.json")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: google-discovery-mcp generate (-api NAME [-version VERSION] | -file PATH) [flags]\n")
+		fmt.Fprintf(stderr, "       google-discovery-mcp list\n")
+		fmt.Fprintf(stderr, "       google-discovery-mcp list-methods (-api NAME [-version VERSION] | -file PATH)\n")
+		fmt.Fprintf(stderr, "       google-discovery-mcp diff OLD.json NEW.json\n\n")
+		fmt.Fprintf(stderr, "Without a subcommand the legacy flags below are accepted:\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	log := newStatusLogger(stderr, src.quiet)
+
+	if listAPIs {
+		configureCache(src.noCache)
+		return doListAPIs(stdout, log, grouped, hideDeprecated, "")
+	}
+	if diff {
+		return doDiff(stdout, fs.Args())
+	}
+	if src.printURL {
+		return src.writeURL(stdout)
+	}
+
+	if src.file == "" && src.apiName == "" {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if err := checkMethodsRegex(gen.methodsRegex); err != nil {
+		return err
+	}
+	doc, err := src.load(log)
+	if err != nil {
+		return err
+	}
+
+	if listMethods {
+		doListMethods(stdout, doc)
+		return nil
+	}
+	return doGenerate(doc, &gen, stdout, log)
+}
+
+// checkMethodsRegex reports an invalid -methods-regex before anything is fetched.
+func checkMethodsRegex(expr string) error {
+	if _, err := regexp.Compile(expr); err != nil {
+		return fmt.Errorf("invalid -methods-regex: %w", err)
+	}
+	return nil
+}
+
+// doListMethods prints a one-line summary of every method in doc.
+func doListMethods(w io.Writer, doc *discovery.Document) {
+	summaries := doc.MethodSummaries()
+	fmt.Fprintf(w, "Methods in %s:\n\n", doc.Name)
+	for _, m := range summaries {
+		desc := m.Description
+		if len(desc) > 80 {
+			desc = desc[:77] + "..."
 		}
-		fmt.Printf("\nTotal: %d methods\n", len(doc.AllMethods()))
-		return
+		desc = strings.ReplaceAll(desc, "\n", " ")
+		fmt.Fprintf(w, "  %-40s %-6s %s\n", m.Name, m.HTTPMethod, desc)
 	}
+	fmt.Fprintf(w, "\nTotal: %d methods\n", len(summaries))
+}
 
-	// Generate code
-	opts := discovery.GenerateOptions{
-		PackageName:    *pkg,
-		Prefix:         *prefix,
-		StructPrefix:   *structPrefix,
-		GenerateSchema: *generateSchema,
+// doGenerate generates code for doc and writes it to -output, or to stdout.
+func doGenerate(doc *discovery.Document, gen *generateFlags, stdout io.Writer, log *statusLogger) error {
+	opts, err := gen.options()
+	if err != nil {
+		return err
+	}
+	if gen.unknownTypes {
+		unknown, err := discovery.UnknownTypes(doc, opts)
+		if err != nil {
+			return fmt.Errorf("generating code: %w", err)
+		}
+		for _, u := range unknown {
+			log.warnf("unmapped %s\n", u)
+		}
+	}
+	if !opts.Strict {
+		problems, err := discovery.DocumentProblems(doc, opts)
+		if err != nil {
+			return fmt.Errorf("generating code: %w", err)
+		}
+		for _, p := range problems {
+			log.warnf("%s\n", p)
+		}
+	}
+	inputless, err := discovery.InputlessMethods(doc, opts)
+	if err != nil {
+		return fmt.Errorf("generating code: %w", err)
+	}
+	for _, name := range inputless {
+		log.warnf("method %s takes no parameters and no request body; its tool has no input\n", name)
 	}
-	if *methods != "" {
-		opts.Methods = strings.Split(*methods, ",")
+
+	if gen.replaceRegion && (gen.output == "" || isDirOutput(gen.output)) {
+		return errors.New("-replace-region requires a file -output")
+	}
+	if isDirOutput(gen.output) {
+		files, err := discovery.GenerateFiles(doc, opts)
+		if err != nil {
+			return fmt.Errorf("generating code: %w", err)
+		}
+		if err := writeFiles(gen.output, files); err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+		log.logf("Generated %d files in %s\n", len(files), gen.output)
+		return nil
 	}
 
+	if opts.Subpackage != "" {
+		return errors.New("-subpackage requires a directory -output")
+	}
+	if opts.SeparateSchemaFile {
+		return errors.New("-separate-schema-file requires a directory -output")
+	}
 	code, err := discovery.GenerateMCPTools(doc, opts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating code: %v\n", err)
 		// Print the code anyway for debugging
 		if code != "" {
-			fmt.Println(code)
+			fmt.Fprintln(stdout, code)
 		}
-		os.Exit(1)
+		return fmt.Errorf("generating code: %w", err)
 	}
 
-	// Output
-	if *output != "" {
-		if err := os.WriteFile(*output, []byte(code), 0o644); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
-			os.Exit(1)
+	if gen.output == "" {
+		if opts.GenerateTests {
+			return errors.New("-tests requires -output")
+		}
+		if opts.ToolsJSON {
+			return errors.New("-tools-json requires -output")
+		}
+		fmt.Fprintln(stdout, code)
+		return nil
+	}
+	if gen.replaceRegion {
+		existing, err := os.ReadFile(gen.output) //nolint:gosec // Path is from user input, but this is a CLI tool
+		if err != nil {
+			return fmt.Errorf("-replace-region: %w", err)
+		}
+		if code, err = replaceRegion(string(existing), code); err != nil {
+			return fmt.Errorf("-replace-region: %s: %w", gen.output, err)
 		}
-		fmt.Fprintf(os.Stderr, "Generated %s\n", *output)
-	} else {
-		fmt.Println(code)
 	}
+	outputs := map[string]string{gen.output: code}
+	if opts.GenerateTests {
+		testCode, err := discovery.GenerateTestFile(doc, opts)
+		if err != nil {
+			return fmt.Errorf("generating tests: %w", err)
+		}
+		outputs[testFileName(gen.output)] = testCode
+	}
+	if opts.ToolsJSON {
+		toolsJSON, err := discovery.GenerateToolsJSON(doc, opts)
+		if err != nil {
+			return fmt.Errorf("generating tools.json: %w", err)
+		}
+		outputs[filepath.Join(filepath.Dir(gen.output), "tools.json")] = toolsJSON
+	}
+	for _, path := range slices.Sorted(maps.Keys(outputs)) {
+		written, err := writeIfChanged(path, []byte(outputs[path]))
+		if err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+		if written {
+			log.logf("Generated %s\n", path)
+		} else {
+			log.logf("%s unchanged\n", path)
+		}
+	}
+	return nil
 }
 
-func doListAPIs() error {
-	fmt.Fprintf(os.Stderr, "Fetching API list from googleapis.com...\n")
-	apis, err := discovery.ListAPIs()
+// Sentinel lines delimiting the generated region of a file written with -replace-region.
+const (
+	regionBegin = "// BEGIN generated"
+	regionEnd   = "// END generated"
+)
+
+// generatedHeader marks a whole file as generated; replaceRegion swaps it for
+// regionHeader, which marks only the region.
+const (
+	generatedHeader = "// Code generated by google-discovery-mcp. DO NOT EDIT."
+	regionHeader    = "// Generated by google-discovery-mcp. Do not edit up to the END line."
+)
+
+// replaceRegion returns existing with the lines between its regionBegin and
+// regionEnd lines replaced by the generated code after its package clause,
+// gofmt'd. Everything outside the sentinels is kept as written, so the region
+// must follow the file's own imports and precede its declarations.
+//
+// The generated header is kept at the top of the region, reworded so it no
+// longer marks the whole file as generated. Generated imports the file already
+// has are dropped and the rest get their own import declaration; an import
+// that would clash with one of the file's is an error. Generated build
+// constraints are added to a file without any and must match a file's own.
+func replaceRegion(existing, code string) (string, error) {
+	lines := strings.SplitAfter(existing, "\n")
+	begin, end := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case regionBegin:
+			if begin != -1 {
+				return "", fmt.Errorf("more than one %q line", regionBegin)
+			}
+			begin = i
+		case regionEnd:
+			if end != -1 {
+				return "", fmt.Errorf("more than one %q line", regionEnd)
+			}
+			end = i
+		}
+	}
+	switch {
+	case begin == -1:
+		return "", fmt.Errorf("no %q line", regionBegin)
+	case end == -1:
+		return "", fmt.Errorf("no %q line", regionEnd)
+	case end < begin:
+		return "", fmt.Errorf("%q line before %q line", regionEnd, regionBegin)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", existing, parser.ParseComments|parser.ImportsOnly)
+	if err != nil {
+		return "", fmt.Errorf("parsing existing file: %w", err)
+	}
+	gen, err := parser.ParseFile(fset, "", code, parser.ParseComments|parser.ImportsOnly)
+	if err != nil {
+		return "", fmt.Errorf("parsing generated code: %w", err)
+	}
+
+	fileBuild, _ := splitHeader(existing[:fset.Position(file.Package).Offset])
+	genBuild, genHeader := splitHeader(code[:fset.Position(gen.Package).Offset])
+	var prefix string
+	switch {
+	case len(genBuild) == 0:
+	case len(fileBuild) == 0:
+		prefix = strings.Join(genBuild, "\n") + "\n\n"
+	case !slices.Equal(fileBuild, genBuild):
+		return "", fmt.Errorf("build constraints %q differ from the file's %q", genBuild, fileBuild)
+	}
+
+	names := make(map[string]string) // import name to path
+	paths := make(map[string]string) // import path to name
+	regionStart := len(strings.Join(lines[:begin], ""))
+	regionStop := len(strings.Join(lines[:end], ""))
+	for _, spec := range file.Imports {
+		if off := fset.Position(spec.Pos()).Offset; off > regionStart && off < regionStop {
+			continue // Generated last time, replaced below.
+		}
+		p, name := importNameOf(spec)
+		names[name], paths[p] = p, name
+	}
+	var imports []string
+	for _, spec := range gen.Imports {
+		p, name := importNameOf(spec)
+		if have, ok := paths[p]; ok {
+			if have != name {
+				return "", fmt.Errorf("generated import %q as %s clashes with the file's import as %s", p, name, have)
+			}
+			continue
+		}
+		if have, ok := names[name]; ok && name != "_" {
+			return "", fmt.Errorf("generated import %q clashes with the file's import %q, both named %s", p, have, name)
+		}
+		if spec.Name == nil {
+			imports = append(imports, "\t"+spec.Path.Value+"\n")
+		} else {
+			imports = append(imports, "\t"+name+" "+spec.Path.Value+"\n")
+		}
+	}
+
+	body := code[fset.Position(gen.Name.End()).Offset:]
+	if n := len(gen.Decls); n > 0 {
+		body = code[fset.Position(gen.Decls[n-1].End()).Offset:]
+	}
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteString(strings.Join(lines[:begin+1], ""))
+	for _, line := range genHeader {
+		if line == generatedHeader {
+			line = regionHeader
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(imports) > 0 {
+		b.WriteString("\nimport (\n" + strings.Join(imports, "") + ")\n")
+	}
+	b.WriteString("\n" + strings.Trim(body, "\n") + "\n")
+	b.WriteString(strings.Join(lines[end:], ""))
+	out, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("formatting result: %w", err)
+	}
+	return string(out), nil
+}
+
+// splitHeader splits the comment lines before a package clause into its build
+// constraint lines and the rest.
+func splitHeader(header string) (build, rest []string) {
+	for _, line := range strings.Split(header, "\n") {
+		switch {
+		case constraint.IsGoBuild(line) || constraint.IsPlusBuild(line):
+			build = append(build, line)
+		case strings.TrimSpace(line) != "":
+			rest = append(rest, line)
+		}
+	}
+	return build, rest
+}
+
+// importNameOf returns the path of an import spec and the name it is imported
+// as, assuming an unnamed import is named after the last path element.
+func importNameOf(spec *ast.ImportSpec) (importPath, name string) {
+	importPath, _ = strconv.Unquote(spec.Path.Value)
+	if spec.Name != nil {
+		return importPath, spec.Name.Name
+	}
+	return importPath, path.Base(importPath)
+}
+
+// testFileName returns the companion test file for a generated file,
+// e.g. "youtube/tools.go" becomes "youtube/tools_test.go".
+func testFileName(output string) string {
+	return strings.TrimSuffix(output, ".go") + "_test.go"
+}
+
+// readMethodsFile reads method names or globs from a file, one per line.
+// Blank lines and everything after a '#' are ignored.
+func readMethodsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // Path is from user input, but this is a CLI tool
+	if err != nil {
+		return nil, err
+	}
+	var methods []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if line = strings.TrimSpace(line); line != "" {
+			methods = append(methods, line)
+		}
+	}
+	return methods, nil
+}
+
+// doDiff prints a summary of the changes between two local Discovery Documents.
+func doDiff(w io.Writer, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("diff requires exactly two files: OLD.json NEW.json")
+	}
+	oldDoc, err := discovery.LoadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	newDoc, err := discovery.LoadFile(args[1])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[1], err)
+	}
+	fmt.Fprint(w, discovery.DiffDocuments(oldDoc, newDoc).String())
+	return nil
+}
+
+// isDirOutput reports whether -output names a directory: either an existing
+// directory or a path ending in a separator.
+func isDirOutput(path string) bool {
+	if path == "" {
+		return false
+	}
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// writeFiles writes the generated files into dir, creating it and any
+// subdirectories the file names contain if needed.
+func writeFiles(dir string, files map[string]string) error {
+	for name, code := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return err
+		}
+		if _, err := writeIfChanged(file, []byte(code)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeIfChanged writes data to path unless the file already holds exactly
+// those bytes, so regenerating unchanged code leaves mtimes alone. It reports
+// whether the file was written.
+func writeIfChanged(path string, data []byte) (bool, error) {
+	existing, err := os.ReadFile(path) //nolint:gosec // Path is from user input, but this is a CLI tool
+	if err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// statusLogger writes informational status lines. Errors bypass it and always
+// go to stderr.
+type statusLogger struct {
+	w    io.Writer
+	warn io.Writer // Receives warnings, even when quiet
+}
+
+// newStatusLogger returns a logger writing to w, or discarding everything but
+// warnings if quiet is set.
+func newStatusLogger(w io.Writer, quiet bool) *statusLogger {
+	l := &statusLogger{w: w, warn: w}
+	if quiet {
+		l.w = io.Discard
+	}
+	return l
+}
+
+func (l *statusLogger) logf(format string, args ...any) {
+	_, _ = fmt.Fprintf(l.w, format, args...)
+}
+
+// warnf writes a warning line, which -quiet does not suppress.
+func (l *statusLogger) warnf(format string, args ...any) {
+	_, _ = fmt.Fprintf(l.warn, "Warning: "+format, args...)
+}
+
+// configureCache enables the on-disk API directory cache unless noCache is set
+// or there is no user cache directory.
+func configureCache(noCache bool) {
+	dir, err := discovery.DefaultCacheDir()
+	if noCache || err != nil {
+		discovery.SetCache("", 0)
+		return
+	}
+	discovery.SetCache(dir, discovery.DefaultCacheTTL)
+}
+
+// doListAPIs prints every API in the Google APIs directory, read from listFile
+// if set, leaving out deprecated versions if hideDeprecated is set.
+func doListAPIs(w io.Writer, log *statusLogger, grouped, hideDeprecated bool, listFile string) error {
+	var apis []discovery.APIInfo
+	var err error
+	if listFile != "" {
+		apis, err = discovery.LoadAPIListFile(listFile)
+	} else {
+		log.logf("Fetching API list from googleapis.com...\n")
+		apis, err = discovery.ListAPIs()
+	}
 	if err != nil {
 		return err
 	}
+	if hideDeprecated {
+		apis = discovery.WithoutDeprecated(apis)
+	}
+	if grouped {
+		writeGroupedAPIs(w, apis)
+		return nil
+	}
 
-	fmt.Printf("Available Google APIs:\n\n")
+	fmt.Fprintf(w, "Available Google APIs:\n\n")
 	for _, api := range apis {
 		pref := " "
 		if api.Preferred {
 			pref = "*"
 		}
-		fmt.Printf("%s %-30s %-10s %s\n", pref, api.Name, api.Version, api.Title)
+		fmt.Fprintf(w, "%s %-30s %-10s %s\n", pref, api.Name, api.Version, api.Title)
 	}
-	fmt.Printf("\n* = preferred version\n")
-	fmt.Printf("Total: %d APIs\n", len(apis))
+	fmt.Fprintf(w, "\n* = preferred version\n")
+	fmt.Fprintf(w, "Total: %d APIs\n", len(apis))
 	return nil
 }
+
+// writeGroupedAPIs prints one line per API name with all of its versions.
+func writeGroupedAPIs(w io.Writer, apis []discovery.APIInfo) {
+	groups := discovery.GroupAPIs(apis)
+	fmt.Fprintf(w, "Available Google APIs:\n\n")
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		versions := make([]string, len(groups[name]))
+		for i, api := range groups[name] {
+			versions[i] = api.Version
+			if api.Preferred {
+				versions[i] += "*"
+			}
+		}
+		fmt.Fprintf(w, "%-30s %-30s %s\n", name, strings.Join(versions, ", "), groups[name][0].Title)
+	}
+	fmt.Fprintf(w, "\n* = preferred version\n")
+	fmt.Fprintf(w, "Total: %d APIs, %d versions\n", len(groups), len(apis))
+}
@@ -0,0 +1,635 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/birdayz/google-discovery-mcp/discovery"
+)
+
+func TestStatusLogger(t *testing.T) {
+	var buf bytes.Buffer
+	newStatusLogger(&buf, false).logf("Loaded: %s\n", "YouTube")
+	if got := buf.String(); got != "Loaded: YouTube\n" {
+		t.Errorf("logf output = %q, want %q", got, "Loaded: YouTube\n")
+	}
+
+	buf.Reset()
+	newStatusLogger(&buf, true).logf("Loaded: %s\n", "YouTube")
+	if buf.Len() != 0 {
+		t.Errorf("quiet logger wrote %q, want nothing", buf.String())
+	}
+
+	newStatusLogger(&buf, true).warnf("unmapped %s\n", "type")
+	if got := buf.String(); got != "Warning: unmapped type\n" {
+		t.Errorf("quiet warnf output = %q, want the warning", got)
+	}
+}
+
+func TestIsDirOutput(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"", false},
+		{"tools.go", false},
+		{"out/", true},
+		{dir, true},
+		{filepath.Join(dir, "tools.go"), false},
+	}
+	for _, tt := range tests {
+		if got := isDirOutput(tt.path); got != tt.want {
+			t.Errorf("isDirOutput(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "out")
+	files := map[string]string{"doc.go": "package tools\n", "tools.go": "package tools\n", "generated/tools.go": "package generated\n"}
+	if err := writeFiles(dir, files); err != nil {
+		t.Fatalf("writeFiles failed: %v", err)
+	}
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWriteIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.go")
+
+	written, err := writeIfChanged(path, []byte("package tools\n"))
+	if err != nil || !written {
+		t.Fatalf("first write = %v, %v; want true, nil", written, err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err = writeIfChanged(path, []byte("package tools\n"))
+	if err != nil || written {
+		t.Fatalf("identical write = %v, %v; want false, nil", written, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Errorf("mtime changed on identical write: %v, want %v", info.ModTime(), old)
+	}
+
+	written, err = writeIfChanged(path, []byte("package other\n"))
+	if err != nil || !written {
+		t.Fatalf("changed write = %v, %v; want true, nil", written, err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "package other\n" {
+		t.Errorf("content = %q, want %q", got, "package other\n")
+	}
+}
+
+func TestReadMethodsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "methods.txt")
+	content := `# YouTube surface we expose
+videos.list
+videos.insert   # uploads
+
+  playlists.*
+#channels.list
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readMethodsFile(path)
+	if err != nil {
+		t.Fatalf("readMethodsFile failed: %v", err)
+	}
+	want := []string{"videos.list", "videos.insert", "playlists.*"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("readMethodsFile = %q, want %q", got, want)
+	}
+}
+
+func TestRunListMethods(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.json")
+	doc := `{
+  "name": "youtube",
+  "version": "v3",
+  "resources": {
+    "videos": {
+      "methods": {
+        "list": {"id": "youtube.videos.list", "httpMethod": "GET", "description": "List videos"},
+        "insert": {"id": "youtube.videos.insert", "httpMethod": "POST", "description": "Upload a video"}
+      }
+    }
+  }
+}`
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"subcommand", []string{"list-methods", "-quiet", "-file", path}},
+		{"legacy flags", []string{"-list-methods", "-quiet", "-file", path}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(tt.args, &stdout, &stderr); err != nil {
+				t.Fatalf("run(%q) failed: %v\nstderr: %s", tt.args, err, stderr.String())
+			}
+			out := stdout.String()
+			for _, want := range []string{"Methods in youtube:", "videos.insert", "videos.list", "Total: 2 methods"} {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+			if stderr.Len() != 0 {
+				t.Errorf("stderr should be empty with -quiet, got %q", stderr.String())
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"list-methods"}, &stdout, &stderr); err == nil {
+		t.Error("list-methods without a document source should fail")
+	}
+	if err := run([]string{"list-methods", "-schema"}, &stdout, &stderr); err == nil {
+		t.Error("list-methods should reject generate-only flags")
+	}
+}
+
+func TestTestFileName(t *testing.T) {
+	tests := map[string]string{
+		"tools.go":         "tools_test.go",
+		"youtube/tools.go": "youtube/tools_test.go",
+		"generated":        "generated_test.go",
+	}
+	for in, want := range tests {
+		if got := testFileName(in); got != want {
+			t.Errorf("testFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRunPrintURL(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"generate", "-print-url", "-api", "youtube", "-version", "v3"}, "https://www.googleapis.com/discovery/v1/apis/youtube/v3/rest\n"},
+		{[]string{"list-methods", "-print-url", "-api", "drive", "-version", "v2", "-format", "rpc"}, "https://www.googleapis.com/discovery/v1/apis/drive/v2/rpc\n"},
+		{[]string{"-print-url", "-api", "gmail", "-version", "v1"}, "https://www.googleapis.com/discovery/v1/apis/gmail/v1/rest\n"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if err := run(tt.args, &stdout, &stderr); err != nil {
+			t.Fatalf("run(%q) failed: %v", tt.args, err)
+		}
+		if stdout.String() != tt.want {
+			t.Errorf("run(%q) printed %q, want %q", tt.args, stdout.String(), tt.want)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"generate", "-print-url", "-file", "doc.json"}, &stdout, &stderr); err == nil {
+		t.Error("-print-url without -api/-version should fail")
+	}
+}
+
+func TestWriteGroupedAPIs(t *testing.T) {
+	apis := []discovery.APIInfo{
+		{Name: "youtube", Version: "v3", Title: "YouTube Data API v3", Preferred: true},
+		{Name: "drive", Version: "v2", Title: "Google Drive API"},
+		{Name: "drive", Version: "v3", Title: "Google Drive API", Preferred: true},
+	}
+	var buf bytes.Buffer
+	writeGroupedAPIs(&buf, apis)
+	out := buf.String()
+
+	for _, want := range []string{"v3*, v2", "Total: 2 APIs, 3 versions"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "drive") > strings.Index(out, "youtube") {
+		t.Errorf("APIs should be listed by name:\n%s", out)
+	}
+}
+
+func TestRunOpenAPI(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "openapi.json")
+	var stdout, stderr bytes.Buffer
+	args := []string{"openapi", "-quiet", "-file", filepath.Join("discovery", "testdata", "youtube_v3.json"), "-methods", "videos.list", "-output", output}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run(%q) failed: %v\nstderr: %s", args, err, stderr.String())
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"openapi": "3.0.3"`, `"/youtube/v3/videos"`, `"operationId": "youtube.videos.list"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("spec missing %s:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), `"/youtube/v3/videos/{videoId}"`) {
+		t.Error("-methods should limit the paths in the spec")
+	}
+}
+
+func TestRunGenerateUnknownTypes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.json")
+	doc := `{
+  "name": "youtube",
+  "version": "v3",
+  "resources": {
+    "videos": {
+      "methods": {
+        "list": {"id": "youtube.videos.list", "parameters": {"since": {"type": "interval", "location": "query"}}}
+      }
+    }
+  }
+}`
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"generate", "-quiet", "-emit-unknown-types", "-file", path}, &stdout, &stderr); err != nil {
+		t.Fatalf("generate failed: %v\nstderr: %s", err, stderr.String())
+	}
+	if want := "Warning: unmapped type \"interval\" used by videos.list.since\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+	if !strings.Contains(stdout.String(), "type APIVideosListArgs struct") {
+		t.Errorf("code should still be generated:\n%s", stdout.String())
+	}
+}
+
+func TestRunGenerateStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.json")
+	doc := `{
+  "name": "youtube",
+  "resources": {
+    "videos": {
+      "methods": {
+        "get": {"id": "youtube.videos.get", "parameterOrder": ["id", "part"], "parameters": {"id": {"type": "string", "location": "query"}}}
+      }
+    }
+  }
+}`
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"generate", "-quiet", "-file", path}, &stdout, &stderr); err != nil {
+		t.Fatalf("generate failed: %v\nstderr: %s", err, stderr.String())
+	}
+	if want := "Warning: method videos.get: parameterOrder lists unknown parameter \"part\"\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+
+	err := run([]string{"generate", "-quiet", "-strict", "-file", path}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "unknown parameter \"part\"") {
+		t.Errorf("generate -strict = %v, want unknown parameter error", err)
+	}
+}
+
+func TestRunGenerateInputlessWarning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.json")
+	doc := `{
+  "name": "youtube",
+  "resources": {
+    "channels": {
+      "methods": {
+        "mine": {"id": "youtube.channels.mine", "httpMethod": "GET", "path": "channels/mine"}
+      }
+    }
+  }
+}`
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"generate", "-quiet", "-file", path}, &stdout, &stderr); err != nil {
+		t.Fatalf("generate failed: %v\nstderr: %s", err, stderr.String())
+	}
+	if want := "Warning: method channels.mine takes no parameters and no request body; its tool has no input\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+}
+
+func TestRunGenerateReplaceRegion(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "tools.go")
+	const before = `package tools
+
+import "strings"
+
+// Hand-written, kept across regenerations.
+var shout = strings.ToUpper
+
+`
+	const after = `
+func handWritten() string { return shout("kept") }
+`
+	stale := before + "// BEGIN generated\nvar stale = 1\n// END generated\n" + after
+	if err := os.WriteFile(output, []byte(stale), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"generate", "-quiet", "-file", filepath.Join("discovery", "testdata", "youtube_v3.json"), "-output", output, "-replace-region"}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run(%q) failed: %v\nstderr: %s", args, err, stderr.String())
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	if !strings.HasPrefix(got, before+"// BEGIN generated\n") || !strings.HasSuffix(got, "// END generated\n"+after) {
+		t.Errorf("content outside the sentinels changed:\n%s", got)
+	}
+	if strings.Contains(got, "stale") || !strings.Contains(got, "type APIVideosListArgs struct") {
+		t.Errorf("region not replaced by the generated code:\n%s", got)
+	}
+	if strings.Contains(got, "DO NOT EDIT") || strings.Count(got, "package ") != 1 {
+		t.Errorf("region should hold only the generated imports and declarations:\n%s", got)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), output, data, 0); err != nil {
+		t.Errorf("result is not valid Go: %v\n%s", err, got)
+	}
+
+	if err := os.WriteFile(output, []byte(before), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(args, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "no \"// BEGIN generated\" line") {
+		t.Errorf("run without sentinels = %v, want missing sentinel error", err)
+	}
+}
+
+func TestRunGenerateReplaceRegionImports(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "tools.go")
+	const handWritten = `package tools
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// BEGIN generated
+// END generated
+
+func decode(s string) (v any, err error) { return v, json.NewDecoder(strings.NewReader(s)).Decode(&v) }
+`
+	if err := os.WriteFile(output, []byte(handWritten), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"generate", "-quiet", "-schema", "-marshal-json", "-tags", "integration", "-file", filepath.Join("discovery", "testdata", "youtube_v3.json"), "-output", output, "-replace-region"}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run(%q) failed: %v\nstderr: %s", args, err, stderr.String())
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	if !strings.HasPrefix(got, "//go:build integration\n// +build integration\n\npackage tools\n") {
+		t.Errorf("build constraint not kept:\n%s", got)
+	}
+	if !strings.Contains(got, "// BEGIN generated\n"+regionHeader+"\n") {
+		t.Errorf("region should start with the reworded header:\n%s", got)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), output, data, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("result is not valid Go: %v\n%s", err, got)
+	}
+	var imports []string
+	for _, spec := range file.Imports {
+		imports = append(imports, spec.Path.Value)
+	}
+	if want := []string{`"encoding/json"`, `"strings"`, `"reflect"`}; !slices.Equal(imports, want) {
+		t.Errorf("imports = %v, want %v", imports, want)
+	}
+	if ast.IsGenerated(file) {
+		t.Error("a file with hand-written code should not be marked as generated")
+	}
+	if formatted, err := format.Source(data); err != nil || !bytes.Equal(formatted, data) {
+		t.Errorf("result is not gofmt'd (%v):\n%s", err, got)
+	}
+
+	// Regenerating is stable, also with the build constraint now in the file.
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("rerun failed: %v", err)
+	}
+	if again, _ := os.ReadFile(output); !bytes.Equal(again, data) {
+		t.Errorf("regenerating changed the file:\n%s", again)
+	}
+
+	if err := os.WriteFile(output, []byte("//go:build other\n\n"+handWritten), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(args, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "build constraints") {
+		t.Errorf("run with a different build constraint = %v, want build constraints error", err)
+	}
+	clash := strings.Replace(handWritten, `"strings"`, `reflect "example.com/reflect"`, 1)
+	if err := os.WriteFile(output, []byte(clash), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(args, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "clashes") {
+		t.Errorf("run with a clashing import = %v, want clash error", err)
+	}
+}
+
+func TestRunGenerateToolsJSON(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "youtube.go")
+	var stdout, stderr bytes.Buffer
+	args := []string{"generate", "-quiet", "-tools-json", "-file", filepath.Join("discovery", "testdata", "youtube_v3.json"), "-output", output}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run(%q) failed: %v", args, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "tools.json"))
+	if err != nil {
+		t.Fatalf("tools.json not written: %v", err)
+	}
+	if !strings.Contains(string(data), `"struct": "APIVideosListArgs"`) {
+		t.Errorf("tools.json missing videos.list entry:\n%s", data)
+	}
+
+	if err := run([]string{"generate", "-tools-json", "-file", filepath.Join("discovery", "testdata", "youtube_v3.json")}, &stdout, &stderr); err == nil {
+		t.Error("-tools-json without -output should fail")
+	}
+}
+
+func TestGenerateFlagsPackagePath(t *testing.T) {
+	dir := t.TempDir()
+	f := generateFlags{pkg: "internal/youtube", output: dir}
+	opts, err := f.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.PackageName != "youtube" {
+		t.Errorf("PackageName = %q, want youtube for a path with directory output", opts.PackageName)
+	}
+
+	f = generateFlags{pkg: "internal/youtube", output: filepath.Join(dir, "tools.go")}
+	if opts, _ = f.options(); opts.PackageName != "internal/youtube" {
+		t.Errorf("PackageName = %q, want the path unchanged (and rejected later) for file output", opts.PackageName)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = run([]string{"generate", "-quiet", "-package", "foo-bar", "-file", filepath.Join("discovery", "testdata", "youtube_v3.json")}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "invalid package name") {
+		t.Errorf("generate -package foo-bar = %v, want invalid package name error", err)
+	}
+}
+
+func TestRunGenerateSubpackage(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join("discovery", "testdata", "youtube_v3.json")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"generate", "-quiet", "-file", file, "-package", "example.com/m/youtube", "-subpackage", "generated", "-output", dir}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("generate -subpackage failed: %v\n%s", err, stderr.String())
+	}
+	reexport, err := os.ReadFile(filepath.Join(dir, "generated.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(reexport), "package youtube") || !strings.Contains(string(reexport), `import "example.com/m/youtube/generated"`) {
+		t.Errorf("generated.go should re-export the subpackage from package youtube\n%s", reexport)
+	}
+	tools, err := os.ReadFile(filepath.Join(dir, "generated", "tools.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tools), "package generated") {
+		t.Errorf("generated/tools.go should declare package generated\n%s", tools)
+	}
+
+	err = run([]string{"generate", "-quiet", "-file", file, "-subpackage", "generated"}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "directory -output") {
+		t.Errorf("generate -subpackage to stdout = %v, want directory -output error", err)
+	}
+}
+
+func TestRunGenerateMethodsRegex(t *testing.T) {
+	file := filepath.Join("discovery", "testdata", "youtube_v3.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"generate", "-quiet", "-file", file, "-methods-regex", `^videos\.(list|insert)$`}, &stdout, &stderr); err != nil {
+		t.Fatalf("generate -methods-regex failed: %v\n%s", err, stderr.String())
+	}
+	code := stdout.String()
+	if !strings.Contains(code, "type APIVideosListArgs struct") || !strings.Contains(code, "type APIVideosInsertArgs struct") || strings.Contains(code, "APIVideosGetArgs") {
+		t.Errorf("only videos.list and videos.insert should be generated:\n%s", code)
+	}
+
+	// An invalid regex fails before the API is fetched.
+	err := run([]string{"generate", "-quiet", "-api", "does-not-exist", "-version", "v1", "-methods-regex", "videos.(list"}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "invalid -methods-regex") {
+		t.Errorf("generate with invalid regex = %v, want invalid -methods-regex error", err)
+	}
+}
+
+func TestRunListFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"list", "-grouped", "-list-file", filepath.Join("discovery", "testdata", "directory.json")}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run(%q) failed: %v", args, err)
+	}
+	for _, want := range []string{"v3*, v2", "YouTube Data API v3", "Total: 2 APIs, 3 versions"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("listing a file should not report fetching, got %q", stderr.String())
+	}
+}
+
+// fileTransport answers every request with the contents of the file it names.
+type fileTransport string
+
+func (path fileTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	data, err := os.ReadFile(string(path))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func TestRunLegacyListGrouped(t *testing.T) {
+	discovery.SetHTTPClient(&http.Client{Transport: fileTransport(filepath.Join("discovery", "testdata", "directory.json"))})
+	t.Cleanup(func() { discovery.SetHTTPClient(nil) })
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-list", "-grouped", "-no-cache", "-quiet"}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run(%q) failed: %v", args, err)
+	}
+	for _, want := range []string{"v3*, v2", "Total: 2 APIs, 3 versions"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestRunListHideDeprecated(t *testing.T) {
+	listFile := filepath.Join("discovery", "testdata", "directory_deprecated.json")
+	for _, tt := range []struct {
+		hide bool
+		want []string
+	}{
+		{false, []string{"plus", "drive", "v2", "Total: 3 APIs"}},
+		{true, []string{"drive", "Total: 1 APIs"}},
+	} {
+		args := []string{"list", "-list-file", listFile}
+		if tt.hide {
+			args = append(args, "-hide-deprecated")
+		}
+		var stdout, stderr bytes.Buffer
+		if err := run(args, &stdout, &stderr); err != nil {
+			t.Fatalf("run(%q) failed: %v", args, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("run(%q) output missing %q:\n%s", args, want, stdout.String())
+			}
+		}
+		if tt.hide && (strings.Contains(stdout.String(), "plus") || strings.Contains(stdout.String(), "v2")) {
+			t.Errorf("run(%q) should hide deprecated versions:\n%s", args, stdout.String())
+		}
+	}
+}